@@ -0,0 +1,646 @@
+package kjson
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal128 represents an IEEE 754-2008 decimal128 value: a sign, a
+// coefficient of up to 34 decimal digits, and a base-10 exponent, plus the
+// format's special values (+/-Infinity, quiet and signaling NaN). A finite
+// Decimal128's value is (-1)^Negative * Digits * 10^Exponent, where Digits
+// holds the coefficient's decimal digits with no leading zeros ("0" for
+// zero itself).
+type Decimal128 struct {
+	Negative bool
+	Digits   string
+	Exponent int32
+
+	kind decimalKind
+}
+
+// decimalKind distinguishes a Decimal128's finite/special variants.
+type decimalKind uint8
+
+const (
+	decimalFinite decimalKind = iota
+	decimalInfinity
+	decimalNaN
+	decimalSignalingNaN
+)
+
+// Decimal128 exponent limits, matching the IEEE 754-2008 decimal128
+// interchange format: a 14-bit biased exponent with bias 6176.
+const (
+	decimal128ExponentBias = 6176
+	decimal128MaxExponent  = 6111
+	decimal128MinExponent  = -6176
+	decimal128MaxDigits    = 34
+)
+
+// RoundingMode selects how Decimal128.Quo rounds a quotient that doesn't
+// terminate within decimal128's 34 significant digits.
+type RoundingMode int
+
+const (
+	// RoundHalfEven rounds to the nearest value, breaking ties toward the
+	// neighbor with an even last digit. This is IEEE 754's default
+	// "banker's rounding" and the mode Add, Sub, Mul, and Quantize use
+	// internally.
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp rounds to the nearest value, breaking ties away from zero.
+	RoundHalfUp
+	// RoundDown truncates toward zero.
+	RoundDown
+	// RoundCeiling rounds toward positive infinity.
+	RoundCeiling
+	// RoundFloor rounds toward negative infinity.
+	RoundFloor
+)
+
+// Decimal128NaN returns a quiet not-a-number Decimal128, mirroring math.NaN.
+func Decimal128NaN() *Decimal128 {
+	return &Decimal128{kind: decimalNaN}
+}
+
+// Decimal128Inf returns positive or negative Decimal128 infinity depending
+// on negative, mirroring math.Inf.
+func Decimal128Inf(negative bool) *Decimal128 {
+	return &Decimal128{Negative: negative, kind: decimalInfinity}
+}
+
+// NewDecimal128 creates a Decimal128 from a string representation,
+// accepting "NaN", "Infinity", and "-Infinity" in addition to ordinary
+// decimal and scientific notation.
+func NewDecimal128(s string) (*Decimal128, error) {
+	switch s {
+	case "NaN":
+		return Decimal128NaN(), nil
+	case "Infinity", "+Infinity":
+		return Decimal128Inf(false), nil
+	case "-Infinity":
+		return Decimal128Inf(true), nil
+	}
+
+	// Parse the decimal string
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative = true
+		s = s[1:]
+	}
+
+	// Find decimal point
+	dotIndex := strings.Index(s, ".")
+	exponent := int32(0)
+	digits := s
+
+	if dotIndex != -1 {
+		// Has decimal point
+		integerPart := s[:dotIndex]
+		fractionalPart := s[dotIndex+1:]
+
+		// Remove leading zeros from integer part
+		integerPart = strings.TrimLeft(integerPart, "0")
+		if integerPart == "" {
+			integerPart = "0"
+		}
+
+		// Preserve the fractional digits and exponent exactly as parsed:
+		// decimal128 cohorts are significant (1.50 and 1.5 are distinct
+		// values with the same mathematical value but different scale).
+		exponent = -int32(len(fractionalPart))
+
+		if fractionalPart == "" {
+			digits = integerPart
+		} else {
+			digits = integerPart + fractionalPart
+		}
+	}
+
+	// Handle scientific notation
+	if eIndex := strings.IndexAny(s, "eE"); eIndex != -1 {
+		mantissa := s[:eIndex]
+		expStr := s[eIndex+1:]
+
+		exp, err := strconv.ParseInt(expStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %s", expStr)
+		}
+
+		// Parse mantissa
+		dotIndex := strings.Index(mantissa, ".")
+		if dotIndex != -1 {
+			integerPart := mantissa[:dotIndex]
+			fractionalPart := mantissa[dotIndex+1:]
+			digits = strings.TrimLeft(integerPart, "0") + fractionalPart
+			exponent = int32(exp) - int32(len(fractionalPart))
+		} else {
+			digits = strings.TrimLeft(mantissa, "0")
+			exponent = int32(exp)
+		}
+	}
+
+	// Remove leading zeros
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		digits = "0"
+		exponent = 0
+		negative = false
+	}
+
+	return &Decimal128{
+		Negative: negative,
+		Digits:   digits,
+		Exponent: exponent,
+	}, nil
+}
+
+// NewDecimal128FromFloat creates a Decimal128 from a float64, carrying
+// over NaN and +/-Inf as Decimal128's own special values.
+func NewDecimal128FromFloat(f float64) *Decimal128 {
+	if math.IsNaN(f) {
+		return Decimal128NaN()
+	}
+	if math.IsInf(f, 0) {
+		return Decimal128Inf(f < 0)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	d, _ := NewDecimal128(s) // Should not error for valid float
+	return d
+}
+
+// IsNaN reports whether d is a quiet or signaling not-a-number.
+func (d *Decimal128) IsNaN() bool {
+	return d.kind == decimalNaN || d.kind == decimalSignalingNaN
+}
+
+// IsInf reports whether d is positive or negative infinity.
+func (d *Decimal128) IsInf() bool {
+	return d.kind == decimalInfinity
+}
+
+// IsZero reports whether d is a finite zero (either signed zero).
+func (d *Decimal128) IsZero() bool {
+	return d.kind == decimalFinite && (d.Digits == "" || d.Digits == "0")
+}
+
+// Neg returns d with its sign flipped. NaN stays NaN.
+func (d *Decimal128) Neg() *Decimal128 {
+	r := *d
+	r.Negative = !d.Negative
+	return &r
+}
+
+// Cmp compares d and other, returning -1, 0, or +1 as d is less than,
+// equal to, or greater than other. Since NaN is unordered, Cmp returns 0
+// if either operand is NaN; callers that care should check IsNaN first.
+func (d *Decimal128) Cmp(other *Decimal128) int {
+	if d.IsNaN() || other.IsNaN() {
+		return 0
+	}
+	if d.IsInf() || other.IsInf() {
+		dv, ov := decimal128OrderValue(d), decimal128OrderValue(other)
+		switch {
+		case dv < ov:
+			return -1
+		case dv > ov:
+			return 1
+		default:
+			return 0
+		}
+	}
+	c1, c2, _ := alignedCoefficients(d, other)
+	return c1.Cmp(c2)
+}
+
+// decimal128OrderValue ranks a Decimal128 by sign and magnitude class, wide
+// enough apart that Infinity always outranks any finite value.
+func decimal128OrderValue(d *Decimal128) int {
+	if d.kind == decimalInfinity {
+		if d.Negative {
+			return -2
+		}
+		return 2
+	}
+	if d.IsZero() {
+		return 0
+	}
+	if d.Negative {
+		return -1
+	}
+	return 1
+}
+
+// Add returns d + other, rounded to 34 significant digits with
+// RoundHalfEven if the exact sum needs more precision than that.
+func (d *Decimal128) Add(other *Decimal128) *Decimal128 {
+	if r := combineSpecialsAdd(d, other); r != nil {
+		return r
+	}
+	c1, c2, exp := alignedCoefficients(d, other)
+	sum := new(big.Int).Add(c1, c2)
+	negative := sum.Sign() < 0
+	if sum.Sign() == 0 {
+		negative = d.Negative && other.Negative
+	}
+	return finalizeResult(negative, new(big.Int).Abs(sum), false, exp, RoundHalfEven)
+}
+
+// Sub returns d - other, rounded the same way as Add.
+func (d *Decimal128) Sub(other *Decimal128) *Decimal128 {
+	return d.Add(other.Neg())
+}
+
+// combineSpecialsAdd handles the NaN/Infinity cases of Add, returning nil
+// when both operands are finite and ordinary addition should proceed.
+func combineSpecialsAdd(a, b *Decimal128) *Decimal128 {
+	if a.IsNaN() || b.IsNaN() {
+		return Decimal128NaN()
+	}
+	if a.IsInf() && b.IsInf() {
+		if a.Negative != b.Negative {
+			return Decimal128NaN()
+		}
+		return Decimal128Inf(a.Negative)
+	}
+	if a.IsInf() {
+		return Decimal128Inf(a.Negative)
+	}
+	if b.IsInf() {
+		return Decimal128Inf(b.Negative)
+	}
+	return nil
+}
+
+// Mul returns d * other, rounded to 34 significant digits with
+// RoundHalfEven if the exact product needs more precision than that.
+func (d *Decimal128) Mul(other *Decimal128) *Decimal128 {
+	negative := d.Negative != other.Negative
+	if d.IsNaN() || other.IsNaN() {
+		return Decimal128NaN()
+	}
+	if d.IsInf() || other.IsInf() {
+		if d.IsZero() || other.IsZero() {
+			return Decimal128NaN()
+		}
+		return Decimal128Inf(negative)
+	}
+	product := new(big.Int).Mul(coeffBigInt(d), coeffBigInt(other))
+	return finalizeResult(negative, product, false, d.Exponent+other.Exponent, RoundHalfEven)
+}
+
+// Quo returns d / other, rounded to 34 significant digits using mode.
+func (d *Decimal128) Quo(other *Decimal128, mode RoundingMode) *Decimal128 {
+	negative := d.Negative != other.Negative
+	if d.IsNaN() || other.IsNaN() {
+		return Decimal128NaN()
+	}
+	if d.IsInf() && other.IsInf() {
+		return Decimal128NaN()
+	}
+	if d.IsInf() {
+		return Decimal128Inf(negative)
+	}
+	if other.IsInf() {
+		return &Decimal128{Negative: negative, Digits: "0"}
+	}
+	if other.IsZero() {
+		if d.IsZero() {
+			return Decimal128NaN()
+		}
+		return Decimal128Inf(negative)
+	}
+	if d.IsZero() {
+		return &Decimal128{Negative: negative, Digits: "0"}
+	}
+
+	num := coeffBigInt(d)
+	den := coeffBigInt(other)
+
+	// Scale the numerator so the integer quotient carries one extra digit
+	// beyond decimal128MaxDigits, giving roundToMaxDigits a digit to round
+	// away instead of rounding on a bare truncated quotient.
+	shift := decimal128MaxDigits + 1 + digitCount(den) - digitCount(num)
+	if shift < 0 {
+		shift = 0
+	}
+	num = new(big.Int).Mul(num, pow10(int32(shift)))
+
+	quo, rem := new(big.Int), new(big.Int)
+	quo.QuoRem(num, den, rem)
+	exp := d.Exponent - other.Exponent - int32(shift)
+
+	return finalizeResult(negative, quo, rem.Sign() != 0, exp, mode)
+}
+
+// Quantize rescales d to exactly exp, rounding with RoundHalfEven when exp
+// is larger than d.Exponent and the rescale would drop digits. NaN and
+// Infinity are returned unchanged.
+func (d *Decimal128) Quantize(exp int32) *Decimal128 {
+	if d.kind != decimalFinite {
+		r := *d
+		return &r
+	}
+
+	diff := exp - d.Exponent
+	coeff := coeffBigInt(d)
+
+	if diff <= 0 {
+		coeff.Mul(coeff, pow10(-diff))
+		return &Decimal128{Negative: d.Negative, Digits: normalizeDigits(coeff.Text(10)), Exponent: exp}
+	}
+
+	rounded, dropped := roundDrop(coeff, d.Negative, RoundHalfEven, int(diff), false)
+	return &Decimal128{Negative: d.Negative, Digits: normalizeDigits(rounded.Text(10)), Exponent: d.Exponent + dropped}
+}
+
+// Round rounds d to prec digits after the decimal point.
+func (d *Decimal128) Round(prec int) *Decimal128 {
+	return d.Quantize(int32(-prec))
+}
+
+// String returns d's canonical decimal string, following the same
+// to-scientific-string rules as MongoDB Extended JSON: plain notation
+// when the exponent is at most 0 and the adjusted exponent is at least -6,
+// scientific notation otherwise.
+func (d *Decimal128) String() string {
+	switch d.kind {
+	case decimalInfinity:
+		if d.Negative {
+			return "-Infinity"
+		}
+		return "Infinity"
+	case decimalNaN, decimalSignalingNaN:
+		return "NaN"
+	}
+
+	sign := ""
+	if d.Negative {
+		sign = "-"
+	}
+
+	digits := d.Digits
+	if digits == "" {
+		digits = "0"
+	}
+	adjusted := int64(d.Exponent) + int64(len(digits)) - 1
+
+	if d.Exponent <= 0 && adjusted >= -6 {
+		if d.Exponent == 0 {
+			return sign + digits
+		}
+		point := len(digits) + int(d.Exponent)
+		if point > 0 {
+			return sign + digits[:point] + "." + digits[point:]
+		}
+		return sign + "0." + strings.Repeat("0", -point) + digits
+	}
+
+	mantissa := digits[:1]
+	if len(digits) > 1 {
+		mantissa += "." + digits[1:]
+	}
+	expSign := "+"
+	if adjusted < 0 {
+		expSign = "-"
+		adjusted = -adjusted
+	}
+	return sign + mantissa + "E" + expSign + strconv.FormatInt(adjusted, 10)
+}
+
+// MarshalBinary encodes d as the 16-byte little-endian IEEE 754-2008
+// decimal128 interchange format (binary integer decimal, "BID"), the same
+// form BSON uses for its Decimal128 type.
+func (d *Decimal128) MarshalBinary() ([]byte, error) {
+	hi, lo, err := d.toBID()
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b[0:8], lo)
+	binary.LittleEndian.PutUint64(b[8:16], hi)
+	return b, nil
+}
+
+// UnmarshalBinary decodes a 16-byte BID decimal128 form produced by
+// MarshalBinary.
+func (d *Decimal128) UnmarshalBinary(data []byte) error {
+	if len(data) != 16 {
+		return fmt.Errorf("kjson: Decimal128.UnmarshalBinary: need 16 bytes, got %d", len(data))
+	}
+	lo := binary.LittleEndian.Uint64(data[0:8])
+	hi := binary.LittleEndian.Uint64(data[8:16])
+	return d.fromBID(hi, lo)
+}
+
+func (d *Decimal128) toBID() (hi, lo uint64, err error) {
+	var sign uint64
+	if d.Negative {
+		sign = 1 << 63
+	}
+
+	switch d.kind {
+	case decimalInfinity:
+		return sign | (0x1E << 58), 0, nil
+	case decimalNaN:
+		return sign | (0x1F << 58), 0, nil
+	case decimalSignalingNaN:
+		return sign | (0x1F << 58) | (1 << 57), 0, nil
+	}
+
+	coeff := new(big.Int)
+	if d.Digits != "" {
+		if _, ok := coeff.SetString(d.Digits, 10); !ok {
+			return 0, 0, fmt.Errorf("kjson: Decimal128 has invalid digits %q", d.Digits)
+		}
+	}
+	if coeff.BitLen() > 113 {
+		return 0, 0, fmt.Errorf("kjson: Decimal128 coefficient overflows 113 bits")
+	}
+
+	biasedExp := int64(d.Exponent) + decimal128ExponentBias
+	if biasedExp < 0 || biasedExp > 0x3FFF {
+		return 0, 0, fmt.Errorf("kjson: Decimal128 exponent %d out of range", d.Exponent)
+	}
+
+	mask64 := new(big.Int).SetUint64(math.MaxUint64)
+	lo = new(big.Int).And(coeff, mask64).Uint64()
+	high49 := new(big.Int).Rsh(coeff, 64).Uint64()
+
+	// A decimal128 coefficient is always < 10^34 < 2^113, so it always
+	// fits in the normal form's 49+64 coefficient bits; the large-
+	// coefficient combination (11) is never needed.
+	hi = high49 | (uint64(biasedExp)&0x3FFF)<<49
+	hi |= sign
+	return hi, lo, nil
+}
+
+func (d *Decimal128) fromBID(hi, lo uint64) error {
+	negative := hi>>63 == 1
+	combination := (hi >> 58) & 0x1F
+
+	if combination>>1 == 0x0F { // top 4 bits of combination are 1111
+		if combination&1 == 0 {
+			*d = Decimal128{Negative: negative, kind: decimalInfinity}
+		} else if hi&(1<<57) != 0 {
+			*d = Decimal128{Negative: negative, kind: decimalSignalingNaN}
+		} else {
+			*d = Decimal128{Negative: negative, kind: decimalNaN}
+		}
+		return nil
+	}
+
+	var biasedExp uint64
+	var high49 uint64
+	if combination>>3 == 3 { // top 2 bits of combination are 11
+		biasedExp = (hi >> 47) & 0x3FFF
+		high49 = (hi & 0x7FFFFFFFFFFF) | (1 << 48)
+	} else {
+		biasedExp = (hi >> 49) & 0x3FFF
+		high49 = hi & 0x1FFFFFFFFFFFF
+	}
+
+	coeff := new(big.Int).Lsh(new(big.Int).SetUint64(high49), 64)
+	coeff.Or(coeff, new(big.Int).SetUint64(lo))
+
+	*d = Decimal128{
+		Negative: negative,
+		Digits:   normalizeDigits(coeff.Text(10)),
+		Exponent: int32(int64(biasedExp) - decimal128ExponentBias),
+	}
+	return nil
+}
+
+// coeffBigInt returns d's coefficient as an unsigned big.Int.
+func coeffBigInt(d *Decimal128) *big.Int {
+	c := new(big.Int)
+	if d.Digits != "" && d.Digits != "0" {
+		c.SetString(d.Digits, 10)
+	}
+	return c
+}
+
+// signedCoeff returns d's coefficient as a signed big.Int.
+func signedCoeff(d *Decimal128) *big.Int {
+	c := coeffBigInt(d)
+	if d.Negative {
+		c.Neg(c)
+	}
+	return c
+}
+
+// alignedCoefficients rescales a and b's coefficients to their smaller
+// exponent so they can be compared or added directly, returning the
+// aligned signed coefficients and that common exponent.
+func alignedCoefficients(a, b *Decimal128) (*big.Int, *big.Int, int32) {
+	ca, cb := signedCoeff(a), signedCoeff(b)
+	exp := a.Exponent
+	if b.Exponent < exp {
+		exp = b.Exponent
+	}
+	if diff := a.Exponent - exp; diff > 0 {
+		ca.Mul(ca, pow10(diff))
+	}
+	if diff := b.Exponent - exp; diff > 0 {
+		cb.Mul(cb, pow10(diff))
+	}
+	return ca, cb, exp
+}
+
+// pow10 returns 10^n as a big.Int.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// digitCount returns how many decimal digits abs's magnitude has, treating
+// zero as one digit.
+func digitCount(abs *big.Int) int {
+	return len(abs.Text(10))
+}
+
+// normalizeDigits strips leading zeros from a coefficient's digit string,
+// collapsing an all-zero result to "0".
+func normalizeDigits(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0"
+	}
+	return digits
+}
+
+// finalizeResult rounds abs to decimal128MaxDigits significant digits with
+// mode and builds the resulting Decimal128, clamping to Infinity or zero
+// if the rounded exponent falls outside decimal128's representable range.
+func finalizeResult(negative bool, abs *big.Int, sticky bool, exp int32, mode RoundingMode) *Decimal128 {
+	rounded, dropped := roundToMaxDigits(abs, negative, mode, sticky)
+	exp += dropped
+
+	if exp > decimal128MaxExponent {
+		return Decimal128Inf(negative)
+	}
+	if exp < decimal128MinExponent {
+		return &Decimal128{Negative: negative, Digits: "0"}
+	}
+
+	return &Decimal128{Negative: negative, Digits: normalizeDigits(rounded.Text(10)), Exponent: exp}
+}
+
+// roundToMaxDigits reduces abs to at most decimal128MaxDigits decimal
+// digits using mode, returning the rounded coefficient and how many
+// digits were dropped (to add to the result's exponent). sticky indicates
+// nonzero digits were already discarded before abs was computed (e.g. a
+// division remainder), which rules out an exact tie during rounding.
+func roundToMaxDigits(abs *big.Int, negative bool, mode RoundingMode, sticky bool) (*big.Int, int32) {
+	drop := digitCount(abs) - decimal128MaxDigits
+	if drop <= 0 {
+		return abs, 0
+	}
+	return roundDrop(abs, negative, mode, drop, sticky)
+}
+
+// roundDrop drops the low `drop` decimal digits from abs according to
+// mode, returning the rounded coefficient and the total number of digits
+// actually dropped (one more than requested if rounding up itself
+// overflowed the digit budget, e.g. 999 -> 1000).
+func roundDrop(abs *big.Int, negative bool, mode RoundingMode, drop int, sticky bool) (*big.Int, int32) {
+	divisor := pow10(int32(drop))
+	quo, rem := new(big.Int), new(big.Int)
+	quo.QuoRem(abs, divisor, rem)
+	if rem.Sign() != 0 {
+		sticky = true
+	}
+
+	roundUp := false
+	twice := new(big.Int).Lsh(rem, 1)
+	switch mode {
+	case RoundDown:
+		roundUp = false
+	case RoundHalfUp:
+		roundUp = twice.Cmp(divisor) >= 0
+	case RoundHalfEven:
+		switch twice.Cmp(divisor) {
+		case 1:
+			roundUp = true
+		case 0:
+			roundUp = quo.Bit(0) == 1
+		}
+	case RoundCeiling:
+		roundUp = sticky && !negative
+	case RoundFloor:
+		roundUp = sticky && negative
+	}
+
+	if roundUp {
+		quo.Add(quo, big.NewInt(1))
+		if digitCount(quo) > decimal128MaxDigits {
+			quo.Quo(quo, big.NewInt(10))
+			drop++
+		}
+	}
+
+	return quo, int32(drop)
+}
@@ -0,0 +1,156 @@
+package kjson
+
+import "testing"
+
+func TestDecimal128Arithmetic(t *testing.T) {
+	a := mustNewDecimal128("1.50")
+	b := mustNewDecimal128("2.3")
+
+	if got := a.Add(b).String(); got != "3.80" {
+		t.Errorf("Add = %s, want 3.80", got)
+	}
+	if got := b.Sub(a).String(); got != "0.80" {
+		t.Errorf("Sub = %s, want 0.80", got)
+	}
+	if got := a.Mul(b).String(); got != "3.450" {
+		t.Errorf("Mul = %s, want 3.450", got)
+	}
+}
+
+func TestDecimal128QuoRoundingModes(t *testing.T) {
+	one := mustNewDecimal128("1")
+	three := mustNewDecimal128("3")
+
+	cases := []struct {
+		mode RoundingMode
+		want string
+	}{
+		{RoundDown, "0.3333333333333333333333333333333333"},
+		{RoundHalfUp, "0.3333333333333333333333333333333333"},
+		{RoundCeiling, "0.3333333333333333333333333333333334"},
+	}
+	for _, c := range cases {
+		if got := one.Quo(three, c.mode).String(); got != c.want {
+			t.Errorf("Quo(mode=%v) = %s, want %s", c.mode, got, c.want)
+		}
+	}
+}
+
+func TestDecimal128Cmp(t *testing.T) {
+	a := mustNewDecimal128("1.1")
+	b := mustNewDecimal128("1.10")
+	c := mustNewDecimal128("1.2")
+
+	if a.Cmp(b) != 0 {
+		t.Errorf("Cmp(1.1, 1.10) = %d, want 0", a.Cmp(b))
+	}
+	if a.Cmp(c) >= 0 {
+		t.Errorf("Cmp(1.1, 1.2) = %d, want negative", a.Cmp(c))
+	}
+	if c.Cmp(a) <= 0 {
+		t.Errorf("Cmp(1.2, 1.1) = %d, want positive", c.Cmp(a))
+	}
+}
+
+func TestDecimal128Quantize(t *testing.T) {
+	d := mustNewDecimal128("1.005")
+
+	if got := d.Quantize(-2).String(); got != "1.00" {
+		t.Errorf("Quantize(-2) = %s, want 1.00", got)
+	}
+	if got := d.Round(2).String(); got != "1.00" {
+		t.Errorf("Round(2) = %s, want 1.00", got)
+	}
+}
+
+func TestDecimal128Predicates(t *testing.T) {
+	if !mustNewDecimal128("0").IsZero() {
+		t.Error("IsZero(0) = false, want true")
+	}
+	if !Decimal128NaN().IsNaN() {
+		t.Error("IsNaN(NaN) = false, want true")
+	}
+	if !Decimal128Inf(false).IsInf() {
+		t.Error("IsInf(Infinity) = false, want true")
+	}
+	if got := mustNewDecimal128("1.5").Neg().String(); got != "-1.5" {
+		t.Errorf("Neg(1.5) = %s, want -1.5", got)
+	}
+}
+
+func TestDecimal128ScientificNotation(t *testing.T) {
+	d := mustNewDecimal128("123E+10")
+	if got := d.String(); got != "1.23E+12" {
+		t.Errorf("String() = %s, want 1.23E+12", got)
+	}
+
+	small := mustNewDecimal128("1E-7")
+	if got := small.String(); got != "1E-7" {
+		t.Errorf("String() = %s, want 1E-7", got)
+	}
+}
+
+func TestDecimal128SpecialValuesRoundTrip(t *testing.T) {
+	cases := []string{"NaN", "Infinity", "-Infinity"}
+	for _, s := range cases {
+		result, err := Marshal(mustNewDecimal128(s))
+		if err != nil {
+			t.Fatalf("Marshal(%s) failed: %v", s, err)
+		}
+		if string(result) != s {
+			t.Errorf("Marshal(%s) = %s, want %s", s, result, s)
+		}
+
+		var parsed Decimal128
+		if err := Unmarshal(result, &parsed); err != nil {
+			t.Fatalf("Unmarshal(%s) failed: %v", s, err)
+		}
+		if parsed.String() != s {
+			t.Errorf("Unmarshal(%s).String() = %s, want %s", s, parsed.String(), s)
+		}
+	}
+}
+
+func TestDecimal128BinaryRoundTrip(t *testing.T) {
+	cases := []string{
+		"0", "99.99", "-1.5",
+		"123456789012345678901234567890.1234",
+		"9999999999999999999999999999999999",
+		"1E+1000",
+	}
+	for _, s := range cases {
+		d := mustNewDecimal128(s)
+		data, err := d.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(%s) failed: %v", s, err)
+		}
+		if len(data) != 16 {
+			t.Fatalf("MarshalBinary(%s) length = %d, want 16", s, len(data))
+		}
+
+		var got Decimal128
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(%s) failed: %v", s, err)
+		}
+		if got.String() != d.String() {
+			t.Errorf("round trip(%s) = %s, want %s", s, got.String(), d.String())
+		}
+	}
+}
+
+func TestDecimal128BinaryRoundTripSpecials(t *testing.T) {
+	for _, d := range []*Decimal128{Decimal128NaN(), Decimal128Inf(false), Decimal128Inf(true)} {
+		data, err := d.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(%s) failed: %v", d, err)
+		}
+
+		var got Decimal128
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(%s) failed: %v", d, err)
+		}
+		if got.String() != d.String() {
+			t.Errorf("round trip(%s) = %s, want %s", d, got.String(), d.String())
+		}
+	}
+}
@@ -0,0 +1,416 @@
+package kjson
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Token is a single lexical element read from a Decoder's input stream,
+// mirroring encoding/json.Token. Delimiters ('[', ']', '{', '}') are
+// returned as Delim values; object keys and strings are returned as
+// string; booleans, numbers, and null are returned as the same Go types
+// Unmarshal produces for interface{}. kJSON's extended literals are
+// returned as their native types - *BigInt, *Decimal128, uuid.UUID, and
+// *Date - which serve as this package's BigInt/Decimal128/UUID/Date
+// tokens.
+type Token interface{}
+
+// Delim is a kJSON array or object delimiter: one of '[', ']', '{', '}'.
+type Delim rune
+
+// String returns the delimiter as a one-character string.
+func (d Delim) String() string {
+	return string(d)
+}
+
+// tokenState tracks where the Decoder is within one open array or object
+// while Token is driving incremental iteration.
+type tokenState struct {
+	isObject bool
+	started  bool // at least one element/pair has been emitted
+	afterKey bool // the last token emitted was an object key
+}
+
+// Decoder reads and decodes kJSON values from an input stream. It mirrors
+// the API of encoding/json.Decoder but understands kJSON's extended
+// BigInt, Decimal128, UUID, and date literals, so huge arrays of them can
+// be processed element-by-element instead of being held fully in memory.
+// Internally it wraps a parser whose data window grows on demand, rather
+// than requiring the whole document up front.
+type Decoder struct {
+	r     *bufio.Reader
+	p     *parser
+	base  int64 // bytes consumed from r before the start of p.data
+	stack []tokenState
+
+	disallowUnknownFields   bool
+	disallowOverflow        bool
+	disallowFractionalToInt bool
+	disallowPrecisionLoss   bool
+}
+
+// DecoderOptions bundles the strict numeric-decoding knobs WithOptions
+// applies to a Decoder in one call.
+type DecoderOptions struct {
+	// DisallowOverflow rejects a number, BigInt, or Decimal128 that
+	// doesn't fit in the destination type instead of truncating it.
+	DisallowOverflow bool
+	// DisallowFractionalToInt rejects a non-integral number decoded into
+	// an int/uint destination instead of truncating it.
+	DisallowFractionalToInt bool
+	// DisallowPrecisionLoss rejects a Decimal128 that can't round-trip
+	// back to an equal value through the destination float type, and a
+	// plain number decoded into a float32 that float32 can't represent
+	// exactly.
+	DisallowPrecisionLoss bool
+}
+
+// NewDecoder returns a new Decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	d := &Decoder{r: bufio.NewReaderSize(r, 4096)}
+	d.p = &parser{fill: d.fill}
+	return d
+}
+
+// fill appends up to one buffered read's worth of bytes to the parser's
+// window. It returns false once r is exhausted.
+func (d *Decoder) fill() bool {
+	chunk := make([]byte, 4096)
+	n, _ := d.r.Read(chunk)
+	if n == 0 {
+		return false
+	}
+	d.p.data += string(chunk[:n])
+	d.p.length = len(d.p.data)
+	return true
+}
+
+// advance commits the parser's progress and drops consumed bytes from the
+// buffered window so a long-running Decoder doesn't retain the whole
+// stream in memory. It folds any newlines in the dropped prefix into
+// p.baseLine so later ParseErrors still report the right line number.
+func (d *Decoder) advance() {
+	d.p.baseLine += strings.Count(d.p.data[:d.p.offset], "\n")
+	d.base += int64(d.p.offset)
+	d.p.data = d.p.data[d.p.offset:]
+	d.p.length = len(d.p.data)
+	d.p.offset = 0
+}
+
+// InputOffset returns the input stream byte offset of the current decoder
+// position.
+func (d *Decoder) InputOffset() int64 {
+	return d.base + int64(d.p.offset)
+}
+
+// UseNumber causes Decode to unmarshal a number into an interface{} as a
+// Number instead of a float64, preserving the original literal text.
+func (d *Decoder) UseNumber() {
+	d.p.useNumber = true
+}
+
+// DisallowComments causes Decode and Token to reject JSON5-style '//' and
+// '/* ... */' comments instead of skipping over them, for callers that want
+// strict kJSON input.
+func (d *Decoder) DisallowComments() {
+	d.p.disallowComments = true
+}
+
+// DisallowUnknownFields causes Decode to return an error when an object
+// key has no matching field on the destination struct, instead of
+// silently ignoring it.
+func (d *Decoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+}
+
+// WithOptions applies opts' strict numeric-decoding knobs to the Decoder
+// and returns it for chaining, e.g. NewDecoder(r).WithOptions(DecoderOptions{DisallowOverflow: true}).
+func (d *Decoder) WithOptions(opts DecoderOptions) *Decoder {
+	d.disallowOverflow = opts.DisallowOverflow
+	d.disallowFractionalToInt = opts.DisallowFractionalToInt
+	d.disallowPrecisionLoss = opts.DisallowPrecisionLoss
+	return d
+}
+
+// More reports whether there is another element in the current array or
+// object being parsed via Token. Outside of any Token-opened container it
+// reports whether another top-level value is available to Decode.
+func (d *Decoder) More() bool {
+	p := d.p
+	if err := p.skipWhitespace(); err != nil {
+		return false
+	}
+	if p.atEnd() {
+		return false
+	}
+	if len(d.stack) == 0 {
+		return true
+	}
+	top := d.stack[len(d.stack)-1]
+	if top.isObject {
+		return p.data[p.offset] != '}'
+	}
+	return p.data[p.offset] != ']'
+}
+
+// Decode reads the next kJSON-encoded value from its input and stores it
+// in the value pointed to by v. When called after Token has descended
+// into an array (or read an object key), Decode reads just the next
+// element or value; it returns io.EOF once the stream, or the current
+// container, is exhausted.
+func (d *Decoder) Decode(v interface{}) error {
+	value, err := d.decodeNextValue()
+	if err != nil {
+		return err
+	}
+	opts := unmarshalOptions{
+		disallowUnknownFields:   d.disallowUnknownFields,
+		disallowOverflow:        d.disallowOverflow,
+		disallowFractionalToInt: d.disallowFractionalToInt,
+		disallowPrecisionLoss:   d.disallowPrecisionLoss,
+	}
+	return fromKJsonValueOpts(value, v, opts)
+}
+
+// decodeNextValue advances past whatever separator or key is needed for
+// the Decoder's current position and parses the next value.
+func (d *Decoder) decodeNextValue() (*Value, error) {
+	p := d.p
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.atEnd() {
+		return nil, io.EOF
+	}
+
+	if len(d.stack) > 0 {
+		top := &d.stack[len(d.stack)-1]
+		c := p.data[p.offset]
+		if (top.isObject && c == '}') || (!top.isObject && c == ']') {
+			return nil, io.EOF
+		}
+
+		if top.afterKey {
+			if c != ':' {
+				return nil, p.newError(p.offset, "expected ':' after object key")
+			}
+			p.offset++
+			if err := p.skipWhitespace(); err != nil {
+				return nil, err
+			}
+			if p.atEnd() {
+				return nil, p.newError(p.offset, "unexpected end of input")
+			}
+			top.afterKey = false
+		} else {
+			if top.started {
+				if c != ',' {
+					msg := "expected ',' or ']'"
+					if top.isObject {
+						msg = "expected ',' or '}'"
+					}
+					return nil, p.newError(p.offset, msg)
+				}
+				p.offset++
+				if err := p.skipWhitespace(); err != nil {
+					return nil, err
+				}
+				if p.atEnd() {
+					return nil, p.newError(p.offset, "unexpected end of input")
+				}
+			}
+			if top.isObject {
+				return nil, p.newError(p.offset, "cannot Decode an object key; read it with Token")
+			}
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		top.started = true
+		d.advance()
+		return value, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	d.advance()
+	return value, nil
+}
+
+// Token returns the next kJSON token in the input stream, descending into
+// and out of arrays and objects as '[', ']', '{', and '}' Delim tokens so
+// that huge containers can be walked without materializing them fully.
+func (d *Decoder) Token() (Token, error) {
+	p := d.p
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.atEnd() {
+		if len(d.stack) > 0 {
+			return nil, p.newError(p.offset, "unexpected end of input")
+		}
+		return nil, io.EOF
+	}
+
+	if len(d.stack) == 0 {
+		return d.readValueToken()
+	}
+
+	top := &d.stack[len(d.stack)-1]
+	c := p.data[p.offset]
+
+	if top.isObject && c == '}' {
+		p.offset++
+		d.stack = d.stack[:len(d.stack)-1]
+		d.advance()
+		return Delim('}'), nil
+	}
+	if !top.isObject && c == ']' {
+		p.offset++
+		d.stack = d.stack[:len(d.stack)-1]
+		d.advance()
+		return Delim(']'), nil
+	}
+
+	if top.afterKey {
+		if c != ':' {
+			return nil, p.newError(p.offset, "expected ':' after object key")
+		}
+		p.offset++
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.atEnd() {
+			return nil, p.newError(p.offset, "unexpected end of input")
+		}
+		top.afterKey = false
+		tok, err := d.readValueToken()
+		if err != nil {
+			return nil, err
+		}
+		top.started = true
+		return tok, nil
+	}
+
+	if top.started {
+		if c != ',' {
+			msg := "expected ',' or ']'"
+			if top.isObject {
+				msg = "expected ',' or '}'"
+			}
+			return nil, p.newError(p.offset, msg)
+		}
+		p.offset++
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.atEnd() {
+			return nil, p.newError(p.offset, "unexpected end of input")
+		}
+	}
+
+	if top.isObject {
+		tok, err := d.readKeyToken()
+		if err != nil {
+			return nil, err
+		}
+		top.afterKey = true
+		return tok, nil
+	}
+
+	tok, err := d.readValueToken()
+	if err != nil {
+		return nil, err
+	}
+	top.started = true
+	return tok, nil
+}
+
+// readValueToken reads one value at the current position, pushing a new
+// container frame and returning an opening Delim for '[' and '{'.
+func (d *Decoder) readValueToken() (Token, error) {
+	p := d.p
+	switch p.data[p.offset] {
+	case '[':
+		p.offset++
+		d.stack = append(d.stack, tokenState{isObject: false})
+		d.advance()
+		return Delim('['), nil
+	case '{':
+		p.offset++
+		d.stack = append(d.stack, tokenState{isObject: true})
+		d.advance()
+		return Delim('{'), nil
+	default:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		d.advance()
+		return tokenFromValue(value), nil
+	}
+}
+
+// readKeyToken reads an object key at the current position as a plain
+// string, matching the quoted and unquoted (JSON5-style) key syntax that
+// parseObject accepts.
+func (d *Decoder) readKeyToken() (Token, error) {
+	p := d.p
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.atEnd() {
+		return nil, p.newError(p.offset, "expected object key")
+	}
+
+	var key string
+	if c := p.data[p.offset]; c == '"' || c == '\'' || c == '`' {
+		v, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		key = v.String
+	} else {
+		k, err := p.parseUnquotedKey()
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	}
+
+	d.advance()
+	return key, nil
+}
+
+// tokenFromValue converts a fully-parsed scalar Value into its Token
+// representation.
+func tokenFromValue(v *Value) Token {
+	switch v.Type {
+	case TypeNull:
+		return nil
+	case TypeBool:
+		return v.Bool
+	case TypeNumber:
+		if v.Raw != "" {
+			return v.Raw
+		}
+		return v.Number
+	case TypeString:
+		return v.String
+	case TypeBigInt:
+		return v.BigInt
+	case TypeDecimal128:
+		return v.Decimal
+	case TypeUUID:
+		return v.UUID
+	case TypeDate:
+		return v.Date
+	default:
+		return v
+	}
+}
@@ -0,0 +1,589 @@
+package kjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDecoderDecodesSequentialValues(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a:1} {a:2} {a:3}`))
+
+	var got []int
+	for {
+		var v map[string]interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Decode failed: %v", err)
+		}
+		got = append(got, int(v["a"].(float64)))
+	}
+
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("Decode sequence = %v, want [1 2 3]", got)
+	}
+}
+
+func TestDecoderTokenWalksArrayElementByElement(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`[1n, 2n, 3n]`))
+
+	tok, err := dec.Token()
+	if err != nil {
+		t.Fatalf("Token failed: %v", err)
+	}
+	if tok != Delim('[') {
+		t.Fatalf("first token = %v, want '['", tok)
+	}
+
+	var sums int64
+	for dec.More() {
+		var b BigInt
+		if err := dec.Decode(&b); err != nil {
+			t.Fatalf("Decode element failed: %v", err)
+		}
+		n, err := strconv.ParseInt(b.String(), 10, 64)
+		if err != nil {
+			t.Fatalf("unexpected BigInt: %v", err)
+		}
+		sums += n
+	}
+
+	tok, err = dec.Token()
+	if err != nil {
+		t.Fatalf("closing Token failed: %v", err)
+	}
+	if tok != Delim(']') {
+		t.Fatalf("last token = %v, want ']'", tok)
+	}
+
+	if sums != 6 {
+		t.Errorf("sum of elements = %d, want 6", sums)
+	}
+}
+
+func TestDecoderInputOffset(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`123 456`))
+
+	var first int
+	if err := dec.Decode(&first); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if off := dec.InputOffset(); off != 3 {
+		t.Errorf("InputOffset after first value = %d, want 3", off)
+	}
+}
+
+func TestDecoderUseNumberPreservesPrecision(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`9007199254740993`))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	n, ok := v.(Number)
+	if !ok {
+		t.Fatalf("Decode type = %T, want kjson.Number", v)
+	}
+	if n.String() != "9007199254740993" {
+		t.Errorf("Number = %s, want 9007199254740993", n.String())
+	}
+}
+
+func TestNumberConversions(t *testing.T) {
+	n := Number("99.5")
+
+	if f, err := n.Float64(); err != nil || f != 99.5 {
+		t.Errorf("Float64() = %v, %v; want 99.5, nil", f, err)
+	}
+
+	if _, err := n.Int64(); err == nil {
+		t.Error("Int64() on a fractional Number should fail")
+	}
+
+	intN := Number("42")
+	i, err := intN.Int64()
+	if err != nil || i != 42 {
+		t.Errorf("Int64() = %v, %v; want 42, nil", i, err)
+	}
+
+	b, err := intN.BigInt()
+	if err != nil || b.String() != "42" {
+		t.Errorf("BigInt() = %v, %v; want 42, nil", b, err)
+	}
+
+	d, err := n.Decimal128()
+	if err != nil || d.String() != "99.5" {
+		t.Errorf("Decimal128() = %v, %v; want 99.5, nil", d, err)
+	}
+}
+
+func TestEncoderWritesNewlineDelimitedValues(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	if err := enc.Encode(1); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Encode(2); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	if buf.String() != "1\n2\n" {
+		t.Errorf("Encoder output = %q, want %q", buf.String(), "1\n2\n")
+	}
+}
+
+func TestEncoderSetIndent(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(map[string]interface{}{"a": 1}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := "{\n  a: 1\n}\n"
+	if buf.String() != expected {
+		t.Errorf("Encoder indented output = %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestDecoderSkipsComments(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{
+		// leading comment
+		a: 1, /* trailing block comment */
+		b: 2
+	}`))
+
+	var v map[string]interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if v["a"] != float64(1) || v["b"] != float64(2) {
+		t.Errorf("Decode = %v, want a=1 b=2", v)
+	}
+}
+
+func TestDecoderUnterminatedBlockComment(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: 1 /* oops} `))
+
+	var v map[string]interface{}
+	err := dec.Decode(&v)
+	if err == nil {
+		t.Fatal("Decode succeeded, want unterminated block comment error")
+	}
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("error type = %T, want *ParseError", err)
+	}
+}
+
+func TestDecoderDisallowComments(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{a: 1} // trailing`))
+	dec.DisallowComments()
+
+	var v map[string]interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if _, err := dec.Token(); err == nil {
+		t.Fatal("expected an error reading the disallowed trailing comment")
+	}
+}
+
+func TestEncoderSetQuoteStyle(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetQuoteStyle(QuoteStyleDouble)
+
+	if err := enc.Encode("it's fine"); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := `"it's fine"` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Encoder quoted output = %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestEncoderSetQuoteStyleEscapesEmbeddedQuote(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetQuoteStyle(QuoteStyleDouble)
+
+	if err := enc.Encode(`he said "hi"`); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := `"he said \"hi\""` + "\n"
+	if buf.String() != expected {
+		t.Errorf("Encoder quoted output = %q, want %q", buf.String(), expected)
+	}
+
+	var got string
+	if err := Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got != `he said "hi"` {
+		t.Errorf("round-tripped value = %q, want %q", got, `he said "hi"`)
+	}
+}
+
+func TestEncoderSetEscapeHTML(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	if err := enc.Encode("<b>a & b</b>"); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	escaped := "'" + `\u003cb\u003ea \u0026 b\u003c/b\u003e` + "'\n"
+	if buf.String() != escaped {
+		t.Errorf("Encoder HTML-escaped output = %q, want %q", buf.String(), escaped)
+	}
+
+	buf.Reset()
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode("<b>a & b</b>"); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	raw := "'<b>a & b</b>'\n"
+	if buf.String() != raw {
+		t.Errorf("Encoder unescaped output = %q, want %q", buf.String(), raw)
+	}
+}
+
+func TestParseErrorReportsLineColumnAndSnippet(t *testing.T) {
+	_, err := parse("{\n  a: 1,\n  b: @\n}")
+
+	perr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("error type = %T, want *ParseError", err)
+	}
+	if perr.Line != 3 || perr.Column != 6 {
+		t.Errorf("Line/Column = %d/%d, want 3/6", perr.Line, perr.Column)
+	}
+	if !strings.Contains(perr.Snippet, "b: @") || !strings.Contains(perr.Snippet, "^") {
+		t.Errorf("Snippet = %q, want it to contain the offending line and a caret", perr.Snippet)
+	}
+}
+
+func TestDecoderDisallowUnknownFields(t *testing.T) {
+	type point struct {
+		X int `kjson:"x"`
+		Y int `kjson:"y"`
+	}
+
+	dec := NewDecoder(strings.NewReader(`{x: 1, y: 2, z: 3}`))
+	dec.DisallowUnknownFields()
+
+	var p point
+	if err := dec.Decode(&p); err == nil {
+		t.Fatal("Decode succeeded, want an error for the unknown field z")
+	}
+}
+
+func TestUnmarshalErrorReportsFieldPath(t *testing.T) {
+	type address struct {
+		Zip int `kjson:"zip"`
+	}
+	type user struct {
+		Addresses []address `kjson:"addresses"`
+	}
+
+	var u user
+	err := Unmarshal([]byte(`{addresses: [{zip: 0}, {zip: "oops"}]}`), &u)
+	if err == nil {
+		t.Fatal("Unmarshal succeeded, want an error for the non-numeric zip")
+	}
+	var uerr *UnmarshalError
+	if !errors.As(err, &uerr) {
+		t.Fatalf("error type = %T, want *UnmarshalError", err)
+	}
+	if uerr.Path != "/addresses/1/zip" {
+		t.Errorf("Path = %q, want %q", uerr.Path, "/addresses/1/zip")
+	}
+	if uerr.Value != "oops" {
+		t.Errorf("Value = %q, want %q", uerr.Value, "oops")
+	}
+}
+
+func TestDecoderWithOptionsDisallowOverflow(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`300`)).WithOptions(DecoderOptions{DisallowOverflow: true})
+
+	var b int8
+	err := dec.Decode(&b)
+	if err == nil {
+		t.Fatal("Decode succeeded, want an overflow error for 300 into int8")
+	}
+	var overflowErr *UnmarshalOverflowError
+	if !errors.As(err, &overflowErr) {
+		t.Fatalf("Decode error = %v, want *UnmarshalOverflowError", err)
+	}
+}
+
+func TestDecoderWithOptionsDisallowOverflowInt64(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`1e19`)).WithOptions(DecoderOptions{DisallowOverflow: true})
+
+	var n int64
+	err := dec.Decode(&n)
+	if err == nil {
+		t.Fatal("Decode succeeded, want an overflow error for 1e19 into int64")
+	}
+	var overflowErr *UnmarshalOverflowError
+	if !errors.As(err, &overflowErr) {
+		t.Fatalf("Decode error = %v, want *UnmarshalOverflowError", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`-1e19`)).WithOptions(DecoderOptions{DisallowOverflow: true})
+	var u uint64
+	err = dec.Decode(&u)
+	if err == nil {
+		t.Fatal("Decode succeeded, want an overflow error for -1e19 into uint64")
+	}
+	if !errors.As(err, &overflowErr) {
+		t.Fatalf("Decode error = %v, want *UnmarshalOverflowError", err)
+	}
+}
+
+func TestDecoderWithOptionsDisallowFractionalToInt(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`1.5`)).WithOptions(DecoderOptions{DisallowFractionalToInt: true})
+
+	var n int
+	err := dec.Decode(&n)
+	if err == nil {
+		t.Fatal("Decode succeeded, want an error truncating 1.5 into int")
+	}
+	var overflowErr *UnmarshalOverflowError
+	if !errors.As(err, &overflowErr) {
+		t.Fatalf("Decode error = %v, want *UnmarshalOverflowError", err)
+	}
+}
+
+func TestDecoderWithOptionsDisallowPrecisionLoss(t *testing.T) {
+	d, err := NewDecimal128("0.1")
+	if err != nil {
+		t.Fatalf("NewDecimal128 failed: %v", err)
+	}
+	dec := NewDecoder(strings.NewReader(d.String())).WithOptions(DecoderOptions{DisallowPrecisionLoss: true})
+
+	var f float32
+	if err := dec.Decode(&f); err == nil {
+		t.Fatal("Decode succeeded, want a precision-loss error for 0.1 into float32")
+	}
+}
+
+func TestDecoderDefaultIsLossy(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`300`))
+
+	var b int8
+	if err := dec.Decode(&b); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	n := 300
+	want := int8(n)
+	if b != want {
+		t.Errorf("b = %d, want truncated %d", b, want)
+	}
+}
+
+func TestMarshalWithIndent(t *testing.T) {
+	result, err := Marshal(map[string]interface{}{"a": 1}, WithIndent("", "  "))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := "{\n  a: 1\n}"
+	if string(result) != expected {
+		t.Errorf("Marshal output = %q, want %q", result, expected)
+	}
+}
+
+func TestMarshalIndentAppliesPrefixAndIndent(t *testing.T) {
+	result, err := MarshalIndent(map[string]interface{}{"a": 1}, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent failed: %v", err)
+	}
+
+	expected := "{\n  a: 1\n}"
+	if string(result) != expected {
+		t.Errorf("MarshalIndent output = %q, want %q", result, expected)
+	}
+}
+
+func TestUnmarshalWithMaxDepth(t *testing.T) {
+	var v interface{}
+	err := Unmarshal([]byte(`[[1]]`), &v, WithMaxDepth(1))
+	if err == nil {
+		t.Fatal("Unmarshal succeeded, want a max depth error")
+	}
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("error type = %T, want *ParseError", err)
+	}
+}
+
+func TestUnmarshalWithDisallowComments(t *testing.T) {
+	err := Unmarshal([]byte(`{a: 1} // trailing`), &struct{}{}, WithDisallowComments())
+	if err == nil {
+		t.Fatal("Unmarshal succeeded, want a disallowed-comment error")
+	}
+}
+
+func TestMarshalWithBigIntAsString(t *testing.T) {
+	result, err := Marshal(NewBigInt(42), WithBigIntAsString())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := "'42'"
+	if string(result) != expected {
+		t.Errorf("Marshal output = %q, want %q", result, expected)
+	}
+}
+
+func TestMarshalWithDecimal128Precision(t *testing.T) {
+	d, err := NewDecimal128("1.23456")
+	if err != nil {
+		t.Fatalf("NewDecimal128 failed: %v", err)
+	}
+
+	result, err := Marshal(d, WithDecimal128Precision(2))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := "1.23m"
+	if string(result) != expected {
+		t.Errorf("Marshal output = %q, want %q", result, expected)
+	}
+}
+
+func TestMarshalCanonicalSortsObjectKeys(t *testing.T) {
+	data := map[string]interface{}{"zebra": 1, "apple": 2, "mango": 3}
+
+	result, err := MarshalCanonical(data)
+	if err != nil {
+		t.Fatalf("MarshalCanonical failed: %v", err)
+	}
+
+	expected := `{"apple":2,"mango":3,"zebra":1}`
+	if string(result) != expected {
+		t.Errorf("MarshalCanonical output = %q, want %q", result, expected)
+	}
+}
+
+func TestMarshalCanonicalSortsStructFields(t *testing.T) {
+	v := struct {
+		Zebra int `json:"zebra"`
+		Apple int `json:"apple"`
+	}{Zebra: 1, Apple: 2}
+
+	result, err := MarshalCanonical(v)
+	if err != nil {
+		t.Fatalf("MarshalCanonical failed: %v", err)
+	}
+
+	expected := `{"apple":2,"zebra":1}`
+	if string(result) != expected {
+		t.Errorf("MarshalCanonical output = %q, want %q", result, expected)
+	}
+}
+
+func TestMarshalCanonicalOmitsExponentPlus(t *testing.T) {
+	result, err := MarshalCanonical(1e21)
+	if err != nil {
+		t.Fatalf("MarshalCanonical failed: %v", err)
+	}
+
+	if strings.Contains(string(result), "+") {
+		t.Errorf("MarshalCanonical output = %q, want no '+' in the exponent", result)
+	}
+}
+
+func TestMarshalCanonicalForcesDoubleQuotes(t *testing.T) {
+	result, err := MarshalCanonical("it's canonical", WithQuoteStyle(QuoteStyleSmart))
+	if err != nil {
+		t.Fatalf("MarshalCanonical failed: %v", err)
+	}
+
+	expected := `"it's canonical"`
+	if string(result) != expected {
+		t.Errorf("MarshalCanonical output = %q, want %q (WithCanonical must win over an earlier WithQuoteStyle)", result, expected)
+	}
+}
+
+func TestMarshalCanonicalRoundTripsEmbeddedQuotes(t *testing.T) {
+	data := map[string]string{"k": `he said "hi"`}
+
+	result, err := MarshalCanonical(data)
+	if err != nil {
+		t.Fatalf("MarshalCanonical failed: %v", err)
+	}
+
+	expected := `{"k":"he said \"hi\""}`
+	if string(result) != expected {
+		t.Errorf("MarshalCanonical output = %q, want %q", result, expected)
+	}
+
+	var got map[string]string
+	if err := Unmarshal(result, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got["k"] != data["k"] {
+		t.Errorf("round-tripped value = %q, want %q", got["k"], data["k"])
+	}
+}
+
+func TestDefaultOptionsMatchesZeroOptionMarshal(t *testing.T) {
+	data := map[string]interface{}{"text": `Mix 'both' "types"`}
+
+	zero, err := Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	explicit, err := Marshal(data, DefaultOptions()...)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	if string(zero) != string(explicit) {
+		t.Errorf("Marshal with DefaultOptions = %q, want %q", explicit, zero)
+	}
+}
+
+func TestEncoderWritesValueComments(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+
+	v := &Value{
+		Type: TypeObject,
+		Object: map[string]*Value{
+			"port": {
+				Type:            TypeNumber,
+				Number:          8080,
+				LeadingComments: []string{"the listen port"},
+				TrailingComment: "default",
+			},
+		},
+	}
+
+	if err := enc.Encode(v); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	expected := "{\n  // the listen port\n  port: 8080 // default\n}\n"
+	if buf.String() != expected {
+		t.Errorf("Encoder output = %q, want %q", buf.String(), expected)
+	}
+}
@@ -0,0 +1,115 @@
+package kjson
+
+import (
+	"io"
+)
+
+// QuoteStyle selects which quote character an Encoder uses for strings.
+type QuoteStyle int
+
+const (
+	// QuoteStyleSmart picks whichever of ', ", ` needs the fewest escapes,
+	// matching Marshal's existing behavior. It is the default.
+	QuoteStyleSmart QuoteStyle = iota
+	QuoteStyleSingle
+	QuoteStyleDouble
+	QuoteStyleBacktick
+)
+
+// quoteChar returns the fixed quote character for the style, or ok=false
+// for QuoteStyleSmart where the quote is chosen per-string.
+func (style QuoteStyle) quoteChar() (quote rune, ok bool) {
+	switch style {
+	case QuoteStyleSingle:
+		return '\'', true
+	case QuoteStyleDouble:
+		return '"', true
+	case QuoteStyleBacktick:
+		return '`', true
+	default:
+		return 0, false
+	}
+}
+
+// Encoder writes kJSON values to an output stream, mirroring the API of
+// encoding/json.Encoder.
+type Encoder struct {
+	w   io.Writer
+	cfg config
+}
+
+// NewEncoder returns a new Encoder that writes to w. HTML escaping is on
+// by default, matching encoding/json.NewEncoder; call SetEscapeHTML(false)
+// to turn it off.
+func NewEncoder(w io.Writer) *Encoder {
+	enc := &Encoder{w: w, cfg: *resolveConfig(nil)}
+	enc.cfg.escapeHTML = true
+	return enc
+}
+
+// SetIndent instructs the Encoder to format each subsequent Encode call's
+// output with the given prefix and indent string, one array or object
+// member per line, following the same convention as json.Encoder.SetIndent.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.cfg.prefix = prefix
+	enc.cfg.indent = indent
+}
+
+// SetQuoteStyle forces the Encoder to use a specific quote character for
+// strings instead of the default smart selection.
+func (enc *Encoder) SetQuoteStyle(style QuoteStyle) {
+	enc.cfg.quoteStyle = style
+}
+
+// SetEscapeHTML specifies whether problematic HTML characters (<, >, and
+// &) are escaped inside string values using their \uXXXX form, so kJSON
+// output embedded in an HTML document can't be misinterpreted. It is on
+// by default.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.cfg.escapeHTML = on
+}
+
+// Encode writes the kJSON encoding of v to the stream, followed by a
+// newline. If v is a *Value or Value, it is encoded as-is instead of being
+// rebuilt through reflection, so its LeadingComments and TrailingComment
+// are honored; any other v is converted the same way Marshal does.
+func (enc *Encoder) Encode(v interface{}) error {
+	value, err := asKJsonValue(v)
+	if err != nil {
+		return err
+	}
+
+	e := newEncodeState(&enc.cfg)
+	if err := e.encodeValue(value, 0); err != nil {
+		return err
+	}
+
+	for _, c := range value.LeadingComments {
+		if _, err := io.WriteString(enc.w, "// "+c+"\n"); err != nil {
+			return err
+		}
+	}
+	if _, err := enc.w.Write(e.Bytes()); err != nil {
+		return err
+	}
+	if value.TrailingComment != "" {
+		if _, err := io.WriteString(enc.w, " // "+value.TrailingComment); err != nil {
+			return err
+		}
+	}
+	_, err = io.WriteString(enc.w, "\n")
+	return err
+}
+
+// asKJsonValue returns v's Value representation directly when v already is
+// one, instead of reflecting over its fields like an ordinary struct.
+func asKJsonValue(v interface{}) (*Value, error) {
+	switch val := v.(type) {
+	case *Value:
+		return val, nil
+	case Value:
+		return &val, nil
+	default:
+		return toKJsonValue(v)
+	}
+}
@@ -3,7 +3,9 @@
 package kjson
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -13,34 +15,35 @@ import (
 	"github.com/google/uuid"
 )
 
-// Marshal returns the kJSON encoding of v.
-// It follows the same semantics as json.Marshal but supports kjson struct tags.
-func Marshal(v interface{}) ([]byte, error) {
+// Marshal returns the kJSON encoding of v, as configured by opts.
+// It follows the same semantics as json.Marshal but supports kjson struct
+// tags and this package's Option settings.
+func Marshal(v interface{}, opts ...Option) ([]byte, error) {
+	cfg := resolveConfig(opts)
 	value, err := toKJsonValue(v)
 	if err != nil {
 		return nil, err
 	}
-	return stringify(value)
+	return stringify(value, cfg)
 }
 
-// Unmarshal parses the kJSON-encoded data and stores the result in the value pointed to by v.
-// It follows the same semantics as json.Unmarshal but supports kjson struct tags.
-func Unmarshal(data []byte, v interface{}) error {
-	value, err := parse(string(data))
+// Unmarshal parses the kJSON-encoded data and stores the result in the
+// value pointed to by v, as configured by opts.
+// It follows the same semantics as json.Unmarshal but supports kjson
+// struct tags and this package's Option settings.
+func Unmarshal(data []byte, v interface{}, opts ...Option) error {
+	cfg := resolveConfig(opts)
+	value, err := parseWithConfig(string(data), cfg)
 	if err != nil {
 		return err
 	}
 	return fromKJsonValue(value, v)
 }
 
-// MarshalIndent is like Marshal but applies Indent to format the output.
-func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
-	b, err := Marshal(v)
-	if err != nil {
-		return nil, err
-	}
-	// For now, return as-is. We can implement pretty printing later
-	return b, nil
+// MarshalIndent is like Marshal but applies prefix and indent to format
+// the output, the same convention as json.MarshalIndent.
+func MarshalIndent(v interface{}, prefix, indent string, opts ...Option) ([]byte, error) {
+	return Marshal(v, append(opts, WithIndent(prefix, indent))...)
 }
 
 // BigInt represents an arbitrary precision integer for kJSON.
@@ -69,132 +72,38 @@ func (b *BigInt) String() string {
 	return b.Digits
 }
 
-// Decimal128 represents a high-precision decimal for kJSON.
-// It stores the decimal as a string with sign and exponent information.
-type Decimal128 struct {
-	Negative bool
-	Digits   string
-	Exponent int32
-}
-
-// NewDecimal128 creates a Decimal128 from a string representation.
-func NewDecimal128(s string) (*Decimal128, error) {
-	// Parse the decimal string
-	negative := false
-	if strings.HasPrefix(s, "-") {
-		negative = true
-		s = s[1:]
-	}
-	
-	// Find decimal point
-	dotIndex := strings.Index(s, ".")
-	exponent := int32(0)
-	digits := s
-	
-	if dotIndex != -1 {
-		// Has decimal point
-		integerPart := s[:dotIndex]
-		fractionalPart := s[dotIndex+1:]
-		
-		// Remove leading zeros from integer part
-		integerPart = strings.TrimLeft(integerPart, "0")
-		if integerPart == "" {
-			integerPart = "0"
-		}
-		
-		// Remove trailing zeros from fractional part and calculate exponent
-		fractionalPart = strings.TrimRight(fractionalPart, "0")
-		exponent = -int32(len(fractionalPart))
-		
-		if fractionalPart == "" {
-			digits = integerPart
-		} else {
-			digits = integerPart + fractionalPart
-		}
-	}
-	
-	// Handle scientific notation
-	if eIndex := strings.IndexAny(s, "eE"); eIndex != -1 {
-		mantissa := s[:eIndex]
-		expStr := s[eIndex+1:]
-		
-		exp, err := strconv.ParseInt(expStr, 10, 32)
-		if err != nil {
-			return nil, fmt.Errorf("invalid exponent: %s", expStr)
-		}
-		
-		// Parse mantissa
-		dotIndex := strings.Index(mantissa, ".")
-		if dotIndex != -1 {
-			integerPart := mantissa[:dotIndex]
-			fractionalPart := mantissa[dotIndex+1:]
-			digits = strings.TrimLeft(integerPart, "0") + fractionalPart
-			exponent = int32(exp) - int32(len(fractionalPart))
-		} else {
-			digits = strings.TrimLeft(mantissa, "0")
-			exponent = int32(exp)
-		}
-	}
-	
-	// Remove leading zeros
-	digits = strings.TrimLeft(digits, "0")
-	if digits == "" {
-		digits = "0"
-		exponent = 0
-		negative = false
-	}
-	
-	return &Decimal128{
-		Negative: negative,
-		Digits:   digits,
-		Exponent: exponent,
-	}, nil
-}
-
-// NewDecimal128FromFloat creates a Decimal128 from a float64.
-func NewDecimal128FromFloat(f float64) *Decimal128 {
-	s := strconv.FormatFloat(f, 'f', -1, 64)
-	d, _ := NewDecimal128(s) // Should not error for valid float
-	return d
-}
-
-// String returns the string representation of the Decimal128.
-func (d *Decimal128) String() string {
-	result := ""
-	if d.Negative {
-		result += "-"
-	}
-	
-	// Simple formatting - can be enhanced later
-	if d.Exponent == 0 {
-		result += d.Digits
-	} else if d.Exponent > 0 {
-		result += d.Digits
-		for i := int32(0); i < d.Exponent; i++ {
-			result += "0"
-		}
-	} else {
-		// Negative exponent - add decimal point
-		if len(d.Digits) <= int(-d.Exponent) {
-			result += "0."
-			for i := 0; i < int(-d.Exponent)-len(d.Digits); i++ {
-				result += "0"
-			}
-			result += d.Digits
-		} else {
-			pos := len(d.Digits) + int(d.Exponent)
-			result += d.Digits[:pos] + "." + d.Digits[pos:]
-		}
-	}
-	
-	return result
-}
-
 // Instant represents a nanosecond-precision timestamp in Zulu time (UTC).
 type Instant struct {
 	Nanoseconds int64 // Nanoseconds since Unix epoch (UTC)
 }
 
+// ErrInstantRangeOverflow is returned when an Instant would fall outside
+// the range google.protobuf.Timestamp documents as valid:
+// 0001-01-01T00:00:00Z to 9999-12-31T23:59:59.999999999Z.
+var ErrInstantRangeOverflow = errors.New("kjson: Instant outside valid range 0001-01-01T00:00:00Z to 9999-12-31T23:59:59.999999999Z")
+
+// ErrDurationRangeOverflow is returned when a Duration computation
+// overflows the nanosecond-precision range Duration can represent.
+var ErrDurationRangeOverflow = errors.New("kjson: Duration overflows its representable range")
+
+// minInstantTime and maxInstantTime are the Gregorian calendar bounds
+// google.protobuf.Timestamp documents as valid.
+var (
+	minInstantTime = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	maxInstantTime = time.Date(9999, time.December, 31, 23, 59, 59, 999999999, time.UTC)
+)
+
+// validateInstantTime reports ErrInstantRangeOverflow if t falls outside
+// minInstantTime..maxInstantTime. It must run before any conversion to a
+// Unix nanosecond count, since time.Time.UnixNano's result is undefined
+// for times outside roughly 1678-2262.
+func validateInstantTime(t time.Time) error {
+	if t.Before(minInstantTime) || t.After(maxInstantTime) {
+		return ErrInstantRangeOverflow
+	}
+	return nil
+}
+
 // NewInstant creates an Instant from nanoseconds since epoch.
 func NewInstant(nanoseconds int64) *Instant {
 	return &Instant{Nanoseconds: nanoseconds}
@@ -210,9 +119,14 @@ func NewInstantFromSeconds(seconds int64) *Instant {
 	return &Instant{Nanoseconds: seconds * 1_000_000_000}
 }
 
-// NewInstantFromTime creates an Instant from a time.Time.
-func NewInstantFromTime(t time.Time) *Instant {
-	return &Instant{Nanoseconds: t.UnixNano()}
+// NewInstantFromTime creates an Instant from a time.Time, returning
+// ErrInstantRangeOverflow if t falls outside the valid Instant range.
+func NewInstantFromTime(t time.Time) (*Instant, error) {
+	utc := t.UTC()
+	if err := validateInstantTime(utc); err != nil {
+		return nil, err
+	}
+	return &Instant{Nanoseconds: utc.UnixNano()}, nil
 }
 
 // Now returns the current Instant.
@@ -220,7 +134,9 @@ func InstantNow() *Instant {
 	return &Instant{Nanoseconds: time.Now().UnixNano()}
 }
 
-// ParseInstant parses an ISO 8601 string to Instant.
+// ParseInstant parses an ISO 8601 string to Instant, returning
+// ErrInstantRangeOverflow if the parsed time falls outside the valid
+// Instant range.
 func ParseInstant(isoString string) (*Instant, error) {
 	// Parse using time.Parse with RFC3339Nano format
 	t, err := time.Parse(time.RFC3339Nano, isoString)
@@ -231,9 +147,12 @@ func ParseInstant(isoString string) (*Instant, error) {
 			return nil, fmt.Errorf("invalid ISO date string: %s", isoString)
 		}
 	}
-	
+
 	// Convert to UTC
 	utc := t.UTC()
+	if err := validateInstantTime(utc); err != nil {
+		return nil, err
+	}
 	return &Instant{Nanoseconds: utc.UnixNano()}, nil
 }
 
@@ -268,6 +187,12 @@ func (i *Instant) String() string {
 	return i.ToISO8601()
 }
 
+// Validate reports ErrInstantRangeOverflow if i falls outside the range
+// google.protobuf.Timestamp documents as valid.
+func (i *Instant) Validate() error {
+	return validateInstantTime(i.ToTime())
+}
+
 // Duration represents a time span with nanosecond precision.
 type Duration struct {
 	Nanoseconds int64 // Duration in nanoseconds
@@ -308,100 +233,278 @@ func NewDurationFromGoDuration(d time.Duration) *Duration {
 	return &Duration{Nanoseconds: int64(d)}
 }
 
-// ParseDuration parses an ISO 8601 duration string.
+// Nanosecond weights for each ISO 8601 duration designator. Y and M are
+// resolved to a nominal 365.2425-day year and 30.436875-day month, since
+// a Duration has no calendar context to compute them against a specific
+// date; both happen to land on a whole number of seconds, so they stay
+// exact despite being approximations.
+const (
+	nsPerSecond = 1_000_000_000
+	nsPerMinute = 60 * nsPerSecond
+	nsPerHour   = 3600 * nsPerSecond
+	nsPerDay    = 86400 * nsPerSecond
+	nsPerWeek   = 7 * nsPerDay
+	nsPerMonth  = 2_629_746 * nsPerSecond
+	nsPerYear   = 31_556_952 * nsPerSecond
+)
+
+var (
+	// durationExtendedRe matches the alternate PYYYY-MM-DDThh:mm:ss form.
+	durationExtendedRe = regexp.MustCompile(`^(\d+)-(\d{2})-(\d{2})(?:T(\d{2}):(\d{2}):(\d+(?:\.\d+)?))?$`)
+	durationDateRe     = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)Y)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)W)?(?:(\d+(?:\.\d+)?)D)?$`)
+	durationTimeRe     = regexp.MustCompile(`^(?:(\d+(?:\.\d+)?)H)?(?:(\d+(?:\.\d+)?)M)?(?:(\d+(?:\.\d+)?)S)?$`)
+)
+
+// durationComponent pairs one parsed designator's value with its
+// nanosecond-per-unit weight, in the order it appeared in the string.
+type durationComponent struct {
+	nanosPerUnit int64
+	raw          string
+	nominal      bool // Y or M: resolved to a nominal, not exact, unit length
+}
+
+// ParseDuration parses an ISO 8601 duration string, accepting:
+//   - an optional leading "-" (or "+"), so it round-trips the negative
+//     durations ToISO8601 emits
+//   - the basic form PnYnMnWnDTnHnMnS, where any single trailing
+//     component may carry a fractional value per ISO 8601 §4.4.3.2
+//   - the extended PYYYY-MM-DDThh:mm:ss form
+//
+// Y and M are resolved to a nominal 365.2425-day year and 30.436875-day
+// month, since a Duration has no calendar context to compute them
+// precisely. ParseDurationStrict rejects them for callers that need
+// calendar-exact semantics instead.
 func ParseDuration(durationString string) (*Duration, error) {
-	// Match ISO 8601 duration format: P[nD]T[nH][nM][nS]
-	re := regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
-	matches := re.FindStringSubmatch(durationString)
-	if matches == nil {
+	return parseDuration(durationString, true)
+}
+
+// ParseDurationStrict is ParseDuration, except it rejects Y and M
+// components - and the extended PYYYY-MM-DD form, which implies them -
+// as ambiguous.
+func ParseDurationStrict(durationString string) (*Duration, error) {
+	return parseDuration(durationString, false)
+}
+
+func parseDuration(durationString string, allowNominal bool) (*Duration, error) {
+	s := durationString
+	negative := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		negative = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	if !strings.HasPrefix(s, "P") {
 		return nil, fmt.Errorf("invalid ISO duration format: %s", durationString)
 	}
-	
-	var totalNanos int64
-	
-	// Days
-	if matches[1] != "" {
-		days, err := strconv.ParseInt(matches[1], 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid days in duration: %s", durationString)
+	s = s[1:]
+
+	var components []durationComponent
+
+	if m := durationExtendedRe.FindStringSubmatch(s); m != nil {
+		if !allowNominal {
+			return nil, fmt.Errorf("ambiguous Y/M component in strict duration: %s", durationString)
+		}
+		components = append(components,
+			durationComponent{nsPerYear, m[1], true},
+			durationComponent{nsPerMonth, m[2], true},
+			durationComponent{nsPerDay, m[3], false},
+		)
+		if m[4] != "" {
+			components = append(components,
+				durationComponent{nsPerHour, m[4], false},
+				durationComponent{nsPerMinute, m[5], false},
+				durationComponent{nsPerSecond, m[6], false},
+			)
+		}
+	} else {
+		dateSection, timeSection, hasTime := s, "", false
+		if idx := strings.IndexByte(s, 'T'); idx >= 0 {
+			dateSection, timeSection, hasTime = s[:idx], s[idx+1:], true
+		}
+
+		dm := durationDateRe.FindStringSubmatch(dateSection)
+		if dm == nil || (dateSection == "" && !hasTime) {
+			return nil, fmt.Errorf("invalid ISO duration format: %s", durationString)
+		}
+		if dm[1] != "" {
+			components = append(components, durationComponent{nsPerYear, dm[1], true})
+		}
+		if dm[2] != "" {
+			components = append(components, durationComponent{nsPerMonth, dm[2], true})
+		}
+		if dm[3] != "" {
+			components = append(components, durationComponent{nsPerWeek, dm[3], false})
+		}
+		if dm[4] != "" {
+			components = append(components, durationComponent{nsPerDay, dm[4], false})
+		}
+
+		if hasTime {
+			tm := durationTimeRe.FindStringSubmatch(timeSection)
+			if tm == nil {
+				return nil, fmt.Errorf("invalid ISO duration format: %s", durationString)
+			}
+			if tm[1] != "" {
+				components = append(components, durationComponent{nsPerHour, tm[1], false})
+			}
+			if tm[2] != "" {
+				components = append(components, durationComponent{nsPerMinute, tm[2], false})
+			}
+			if tm[3] != "" {
+				components = append(components, durationComponent{nsPerSecond, tm[3], false})
+			}
 		}
-		totalNanos += days * 86400 * 1_000_000_000
 	}
-	
-	// Hours
-	if matches[2] != "" {
-		hours, err := strconv.ParseInt(matches[2], 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid hours in duration: %s", durationString)
+
+	if len(components) == 0 {
+		return nil, fmt.Errorf("invalid ISO duration format: %s", durationString)
+	}
+	if !allowNominal {
+		for _, c := range components {
+			if c.nominal {
+				return nil, fmt.Errorf("ambiguous Y/M component in strict duration: %s", durationString)
+			}
 		}
-		totalNanos += hours * 3600 * 1_000_000_000
 	}
-	
-	// Minutes
-	if matches[3] != "" {
-		minutes, err := strconv.ParseInt(matches[3], 10, 64)
+
+	var totalNanos int64
+	for i, c := range components {
+		fractional := strings.Contains(c.raw, ".")
+		if fractional && i != len(components)-1 {
+			return nil, fmt.Errorf("only the last component of a duration may be fractional: %s", durationString)
+		}
+		if fractional {
+			value, err := strconv.ParseFloat(c.raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid component %q in duration: %s", c.raw, durationString)
+			}
+			product := value * float64(c.nanosPerUnit)
+			if math.IsNaN(product) || product > float64(math.MaxInt64) || product < float64(math.MinInt64) {
+				return nil, ErrDurationRangeOverflow
+			}
+			term := int64(product)
+			newTotal := totalNanos + term
+			if (term > 0 && newTotal < totalNanos) || (term < 0 && newTotal > totalNanos) {
+				return nil, ErrDurationRangeOverflow
+			}
+			totalNanos = newTotal
+			continue
+		}
+		n, err := strconv.ParseInt(c.raw, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("invalid minutes in duration: %s", durationString)
+			return nil, fmt.Errorf("invalid component %q in duration: %s", c.raw, durationString)
 		}
-		totalNanos += minutes * 60 * 1_000_000_000
+		term := n * c.nanosPerUnit
+		if n != 0 && term/n != c.nanosPerUnit {
+			return nil, ErrDurationRangeOverflow
+		}
+		newTotal := totalNanos + term
+		if (term > 0 && newTotal < totalNanos) || (term < 0 && newTotal > totalNanos) {
+			return nil, ErrDurationRangeOverflow
+		}
+		totalNanos = newTotal
 	}
-	
-	// Seconds
-	if matches[4] != "" {
-		seconds, err := strconv.ParseFloat(matches[4], 64)
-		if err != nil {
-			return nil, fmt.Errorf("invalid seconds in duration: %s", durationString)
+
+	if negative {
+		if totalNanos == math.MinInt64 {
+			return nil, ErrDurationRangeOverflow
 		}
-		totalNanos += int64(seconds * 1_000_000_000)
+		totalNanos = -totalNanos
 	}
-	
 	return &Duration{Nanoseconds: totalNanos}, nil
 }
 
-// ToISO8601 converts Duration to ISO 8601 duration string.
-func (d *Duration) ToISO8601() string {
+// DurationFormat controls which designators Duration.ToISO8601 emits.
+type DurationFormat int
+
+const (
+	// DurationFormatExact renders a duration using only D, H, M, and S -
+	// the designators ToISO8601 has always produced - so every digit
+	// stays exact. It is the default.
+	DurationFormatExact DurationFormat = iota
+	// DurationFormatNominal additionally breaks the whole-day count down
+	// into Y, M, and W first, using a 365-day year and a 30-day month (the
+	// nominal year and month ParseDuration uses, truncated to whole days)
+	// and a 7-day week. ParseDuration resolves Y and M to the longer
+	// nominal lengths, so the result does not in general parse back to the
+	// same Duration.
+	DurationFormatNominal
+)
+
+// ToISO8601 converts Duration to an ISO 8601 duration string, using only
+// D, H, M, and S designators unless format requests DurationFormatNominal.
+func (d *Duration) ToISO8601(format ...DurationFormat) string {
+	f := DurationFormatExact
+	if len(format) > 0 {
+		f = format[0]
+	}
+
 	if d.Nanoseconds == 0 {
 		return "PT0S"
 	}
-	
+
 	remaining := d.Nanoseconds
 	negative := remaining < 0
 	if negative {
 		remaining = -remaining
 	}
-	
+
 	var result strings.Builder
 	result.WriteString("P")
-	
-	// Days
-	days := remaining / (86400 * 1_000_000_000)
+
+	// Split off the whole-day count up front; the sub-day remainder
+	// always renders in the T section below, exact or nominal alike.
+	days := remaining / nsPerDay
+	remaining %= nsPerDay
+
+	if f == DurationFormatNominal {
+		const daysPerYear = nsPerYear / nsPerDay
+		const daysPerMonth = nsPerMonth / nsPerDay
+		const daysPerWeek = nsPerWeek / nsPerDay
+
+		if years := days / daysPerYear; years > 0 {
+			result.WriteString(fmt.Sprintf("%dY", years))
+			days %= daysPerYear
+		}
+		if months := days / daysPerMonth; months > 0 {
+			result.WriteString(fmt.Sprintf("%dM", months))
+			days %= daysPerMonth
+		}
+		if weeks := days / daysPerWeek; weeks > 0 {
+			result.WriteString(fmt.Sprintf("%dW", weeks))
+			days %= daysPerWeek
+		}
+	}
+
 	if days > 0 {
 		result.WriteString(fmt.Sprintf("%dD", days))
-		remaining %= 86400 * 1_000_000_000
 	}
-	
+
 	if remaining > 0 {
 		result.WriteString("T")
-		
+
 		// Hours
-		hours := remaining / (3600 * 1_000_000_000)
+		hours := remaining / nsPerHour
 		if hours > 0 {
 			result.WriteString(fmt.Sprintf("%dH", hours))
-			remaining %= 3600 * 1_000_000_000
+			remaining %= nsPerHour
 		}
-		
+
 		// Minutes
-		minutes := remaining / (60 * 1_000_000_000)
+		minutes := remaining / nsPerMinute
 		if minutes > 0 {
 			result.WriteString(fmt.Sprintf("%dM", minutes))
-			remaining %= 60 * 1_000_000_000
+			remaining %= nsPerMinute
 		}
-		
+
 		// Seconds (with fractional part)
 		if remaining > 0 {
-			seconds := remaining / 1_000_000_000
-			nanosPart := remaining % 1_000_000_000
-			
+			seconds := remaining / nsPerSecond
+			nanosPart := remaining % nsPerSecond
+
 			if nanosPart == 0 {
 				result.WriteString(fmt.Sprintf("%dS", seconds))
 			} else {
@@ -411,7 +514,7 @@ func (d *Duration) ToISO8601() string {
 			}
 		}
 	}
-	
+
 	if negative {
 		return "-" + result.String()
 	}
@@ -453,19 +556,34 @@ func (d *Duration) TotalDays() float64 {
 	return float64(d.Nanoseconds) / (86400.0 * 1_000_000_000.0)
 }
 
-// Add adds two durations.
-func (d *Duration) Add(other *Duration) *Duration {
-	return &Duration{Nanoseconds: d.Nanoseconds + other.Nanoseconds}
+// Add adds two durations, returning ErrDurationRangeOverflow if the sum
+// overflows the range a Duration can represent.
+func (d *Duration) Add(other *Duration) (*Duration, error) {
+	sum := d.Nanoseconds + other.Nanoseconds
+	if (other.Nanoseconds > 0 && sum < d.Nanoseconds) || (other.Nanoseconds < 0 && sum > d.Nanoseconds) {
+		return nil, ErrDurationRangeOverflow
+	}
+	return &Duration{Nanoseconds: sum}, nil
 }
 
-// Sub subtracts two durations.
-func (d *Duration) Sub(other *Duration) *Duration {
-	return &Duration{Nanoseconds: d.Nanoseconds - other.Nanoseconds}
+// Sub subtracts two durations, returning ErrDurationRangeOverflow if the
+// difference overflows the range a Duration can represent.
+func (d *Duration) Sub(other *Duration) (*Duration, error) {
+	diff := d.Nanoseconds - other.Nanoseconds
+	if (other.Nanoseconds < 0 && diff < d.Nanoseconds) || (other.Nanoseconds > 0 && diff > d.Nanoseconds) {
+		return nil, ErrDurationRangeOverflow
+	}
+	return &Duration{Nanoseconds: diff}, nil
 }
 
-// Mul multiplies duration by scalar.
-func (d *Duration) Mul(scalar float64) *Duration {
-	return &Duration{Nanoseconds: int64(float64(d.Nanoseconds) * scalar)}
+// Mul multiplies duration by scalar, returning ErrDurationRangeOverflow
+// if the result overflows the range a Duration can represent.
+func (d *Duration) Mul(scalar float64) (*Duration, error) {
+	result := float64(d.Nanoseconds) * scalar
+	if math.IsNaN(result) || result > float64(math.MaxInt64) || result < float64(math.MinInt64) {
+		return nil, ErrDurationRangeOverflow
+	}
+	return &Duration{Nanoseconds: int64(result)}, nil
 }
 
 // Div divides duration by scalar.
@@ -501,6 +619,15 @@ func (d *Duration) String() string {
 	return d.ToISO8601()
 }
 
+// Validate reports ErrDurationRangeOverflow if d falls outside the range
+// a Duration can represent. google.protobuf.Duration documents a range
+// of approximately +/-10,000 years; kjson's Duration is stored as a
+// single nanosecond count, a tighter bound that Add, Sub, and Mul already
+// enforce, so Validate always succeeds for any existing *Duration.
+func (d *Duration) Validate() error {
+	return nil
+}
+
 // Date represents a date with timezone for kJSON (DEPRECATED: use Instant instead).
 type Date struct {
 	Time     time.Time
@@ -527,6 +654,7 @@ type Value struct {
 	Null     interface{}
 	Bool     bool
 	Number   float64
+	Raw      Number // original literal text for TypeNumber when UseNumber is set
 	String   string
 	BigInt   *BigInt
 	Decimal  *Decimal128
@@ -536,6 +664,64 @@ type Value struct {
 	Date     *Date
 	Array    []*Value
 	Object   map[string]*Value
+	Extended *ExtendedValue
+
+	// LeadingComments are '//' comments written on their own lines
+	// immediately above this value. They let callers that build a Value
+	// tree by hand produce commented configuration-file output. A nested
+	// value's comments are written only when the output is indented
+	// (Encoder.SetIndent or WithIndent); Encoder.Encode always writes the
+	// comments of the top-level value it is given.
+	LeadingComments []string
+	// TrailingComment is a single '//' comment written after this value,
+	// on the same line, under the same conditions as LeadingComments.
+	TrailingComment string
+}
+
+// Number represents a kJSON number literal as its original text instead of
+// a float64, so that values too large or precise to round-trip through
+// float64 (e.g. 9007199254740993) keep their exact digits. It mirrors
+// encoding/json.Number and is produced when a Decoder has UseNumber set.
+type Number string
+
+// String returns the literal text of the number.
+func (n Number) String() string {
+	return string(n)
+}
+
+// Int64 parses the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// Float64 parses the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// BigInt converts the number to a BigInt. It fails if the literal has a
+// fractional part or exponent.
+func (n Number) BigInt() (*BigInt, error) {
+	s := string(n)
+	digits := s
+	negative := strings.HasPrefix(s, "-")
+	if negative {
+		digits = s[1:]
+	}
+	if digits == "" {
+		return nil, fmt.Errorf("kjson: %q is not an integer", s)
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("kjson: %q is not an integer", s)
+		}
+	}
+	return &BigInt{Negative: negative, Digits: digits}, nil
+}
+
+// Decimal128 converts the number to a Decimal128.
+func (n Number) Decimal128() (*Decimal128, error) {
+	return NewDecimal128(string(n))
 }
 
 // ValueType represents the type of a kJSON value.
@@ -554,6 +740,11 @@ const (
 	TypeDate
 	TypeArray
 	TypeObject
+	// TypeExtended holds a bare number literal followed by a suffix byte,
+	// for a third-party type registered with RegisterType/RegisterTypeSuffix
+	// that kJSON has no native ValueType for. Words such as NaN or Infinity
+	// followed by a suffix are not recognized. See Value.Extended.
+	TypeExtended
 )
 
 // String returns the string representation of the ValueType.
@@ -583,75 +774,68 @@ func (t ValueType) String() string {
 		return "array"
 	case TypeObject:
 		return "object"
+	case TypeExtended:
+		return "extended"
 	default:
 		return "unknown"
 	}
 }
 
-// getStructTag returns the field name to use for a struct field.
-// It checks for "kjson" tag first, then "json" tag, then uses the field name.
-func getStructTag(field reflect.StructField) (string, bool) {
-	// Check kjson tag first
-	if tag := field.Tag.Get("kjson"); tag != "" {
-		if tag == "-" {
-			return "", false // Skip this field
+// structTag is a struct field's parsed kjson/json tag: the name to
+// encode/decode it under, plus the encoding/json-style options that
+// change how it's handled.
+type structTag struct {
+	name      string
+	omitempty bool
+	inline    bool // flatten this field's own fields into the parent object
+	asString  bool // encode/decode a numeric or bool field as a quoted string
+}
+
+// parseStructTag extracts field's name and options from its "kjson" tag,
+// falling back to "json" so existing encoding/json-tagged structs work
+// unchanged. ok is false when the tag is "-" and the field should be
+// skipped entirely.
+//
+// An anonymous struct (or pointer-to-struct) field with no explicit tag
+// name is inlined automatically, matching encoding/json's embedded-field
+// promotion rules; any other field needs an explicit ",inline" option.
+func parseStructTag(field reflect.StructField) (structTag, bool) {
+	tag, hasTag := field.Tag.Lookup("kjson")
+	if !hasTag {
+		tag, hasTag = field.Tag.Lookup("json")
+	}
+	if tag == "-" {
+		return structTag{}, false
+	}
+
+	parts := strings.Split(tag, ",")
+	st := structTag{name: field.Name}
+	if parts[0] != "" {
+		st.name = parts[0]
+	}
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "omitempty":
+			st.omitempty = true
+		case "inline":
+			st.inline = true
+		case "string":
+			st.asString = true
 		}
-		// Parse tag (handle ",omitempty" etc.)
-		if comma := findComma(tag); comma != -1 {
-			return tag[:comma], true
-		}
-		return tag, true
 	}
-	
-	// Fall back to json tag
-	if tag := field.Tag.Get("json"); tag != "" {
-		if tag == "-" {
-			return "", false // Skip this field
-		}
-		// Parse tag (handle ",omitempty" etc.)
-		if comma := findComma(tag); comma != -1 {
-			return tag[:comma], true
-		}
-		return tag, true
-	}
-	
-	// Use field name if no tags
-	return field.Name, true
-}
 
-// findComma finds the first comma in a string.
-func findComma(s string) int {
-	for i, c := range s {
-		if c == ',' {
-			return i
-		}
+	if field.Anonymous && parts[0] == "" && isStructOrPtrToStruct(field.Type) {
+		st.inline = true
 	}
-	return -1
-}
 
-// isOmitEmpty checks if a struct tag contains "omitempty".
-func isOmitEmpty(field reflect.StructField) bool {
-	// Check kjson tag first
-	if tag := field.Tag.Get("kjson"); tag != "" {
-		return containsOmitEmpty(tag)
-	}
-	
-	// Fall back to json tag
-	if tag := field.Tag.Get("json"); tag != "" {
-		return containsOmitEmpty(tag)
-	}
-	
-	return false
+	return st, true
 }
 
-// containsOmitEmpty checks if a tag contains "omitempty".
-func containsOmitEmpty(tag string) bool {
-	if comma := findComma(tag); comma != -1 {
-		options := tag[comma+1:]
-		// Simple check for omitempty
-		return options == "omitempty" || 
-			   (len(options) > 9 && options[:10] == "omitempty,") ||
-			   (len(options) > 9 && options[len(options)-10:] == ",omitempty")
+// isStructOrPtrToStruct reports whether t is a struct or a pointer to
+// one, the shapes that can be inlined into a parent object.
+func isStructOrPtrToStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
-	return false
+	return t.Kind() == reflect.Struct
 }
\ No newline at end of file
@@ -1,6 +1,8 @@
 package kjson
 
 import (
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -37,6 +39,120 @@ func TestBasicTypes(t *testing.T) {
 	}
 }
 
+// Test Duration negative round trip and range overflow
+func TestDurationNegativeRoundTrip(t *testing.T) {
+	d := NewDurationFromHours(-2)
+
+	iso := d.ToISO8601()
+	if iso != "-PT2H" {
+		t.Fatalf("ToISO8601() = %s, want -PT2H", iso)
+	}
+
+	parsed, err := ParseDuration(iso)
+	if err != nil {
+		t.Fatalf("ParseDuration(%s) failed: %v", iso, err)
+	}
+	if parsed.Nanoseconds != d.Nanoseconds {
+		t.Errorf("ParseDuration(%s) = %d ns, want %d ns", iso, parsed.Nanoseconds, d.Nanoseconds)
+	}
+}
+
+func TestDurationArithmeticOverflow(t *testing.T) {
+	max := NewDuration(math.MaxInt64)
+	one := NewDuration(1)
+
+	if _, err := max.Add(one); err != ErrDurationRangeOverflow {
+		t.Errorf("Add overflow = %v, want ErrDurationRangeOverflow", err)
+	}
+
+	min := NewDuration(math.MinInt64)
+	if _, err := min.Sub(one); err != ErrDurationRangeOverflow {
+		t.Errorf("Sub overflow = %v, want ErrDurationRangeOverflow", err)
+	}
+
+	if _, err := max.Mul(2); err != ErrDurationRangeOverflow {
+		t.Errorf("Mul overflow = %v, want ErrDurationRangeOverflow", err)
+	}
+}
+
+func TestParseDurationRangeOverflow(t *testing.T) {
+	if _, err := ParseDuration("P1000000000000Y"); err != ErrDurationRangeOverflow {
+		t.Errorf("ParseDuration(huge years) = %v, want ErrDurationRangeOverflow", err)
+	}
+	if _, err := ParseDuration("-P1000000000000Y"); err != ErrDurationRangeOverflow {
+		t.Errorf("ParseDuration(huge negative years) = %v, want ErrDurationRangeOverflow", err)
+	}
+}
+
+func TestParseDurationExtendedCoverage(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"P2W", 2 * nsPerWeek},
+		{"-PT1H", -nsPerHour},
+		{"P1Y2M3W4DT5.5H", nsPerYear + 2*nsPerMonth + 3*nsPerWeek + 4*nsPerDay + 5*nsPerHour + nsPerHour/2},
+		{"P0003-06-04T12:30:05", 3*nsPerYear + 6*nsPerMonth + 4*nsPerDay + 12*nsPerHour + 30*nsPerMinute + 5*nsPerSecond},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if err != nil {
+			t.Fatalf("ParseDuration(%q) failed: %v", tt.input, err)
+		}
+		if got.Nanoseconds != tt.want {
+			t.Errorf("ParseDuration(%q) = %d ns, want %d ns", tt.input, got.Nanoseconds, tt.want)
+		}
+	}
+}
+
+func TestParseDurationRejectsNonTrailingFraction(t *testing.T) {
+	if _, err := ParseDuration("P1.5DT1H"); err == nil {
+		t.Fatal("ParseDuration succeeded, want an error for a non-trailing fractional component")
+	}
+}
+
+func TestParseDurationStrictRejectsYearsAndMonths(t *testing.T) {
+	if _, err := ParseDurationStrict("P1Y"); err == nil {
+		t.Fatal("ParseDurationStrict succeeded, want an error for an ambiguous Y component")
+	}
+	if _, err := ParseDurationStrict("P1M"); err == nil {
+		t.Fatal("ParseDurationStrict succeeded, want an error for an ambiguous M component")
+	}
+
+	got, err := ParseDurationStrict("P2W3DT1H")
+	if err != nil {
+		t.Fatalf("ParseDurationStrict failed: %v", err)
+	}
+	want := int64(2*nsPerWeek + 3*nsPerDay + nsPerHour)
+	if got.Nanoseconds != want {
+		t.Errorf("ParseDurationStrict(%q) = %d ns, want %d ns", "P2W3DT1H", got.Nanoseconds, want)
+	}
+}
+
+func TestDurationToISO8601Nominal(t *testing.T) {
+	d := NewDuration(nsPerYear + nsPerMonth + nsPerWeek)
+
+	if got := d.ToISO8601(); got != "P402DT16H18M18S" {
+		t.Errorf("ToISO8601() = %s, want P402DT16H18M18S", got)
+	}
+	if got := d.ToISO8601(DurationFormatNominal); got != "P1Y1M1WT16H18M18S" {
+		t.Errorf("ToISO8601(DurationFormatNominal) = %s, want P1Y1M1WT16H18M18S", got)
+	}
+}
+
+func TestInstantRangeOverflow(t *testing.T) {
+	farFuture := time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := NewInstantFromTime(farFuture); err != ErrInstantRangeOverflow {
+		t.Errorf("NewInstantFromTime(year 10000) = %v, want ErrInstantRangeOverflow", err)
+	}
+
+	if i, err := NewInstantFromTime(time.Now()); err != nil || i.Validate() != nil {
+		t.Errorf("NewInstantFromTime(now) = %v, %v; want a valid Instant", i, err)
+	}
+}
+
 // Test BigInt
 func TestBigInt(t *testing.T) {
 	bigint := NewBigInt(123456789012345678)
@@ -194,6 +310,93 @@ func TestStructWithKJsonTags(t *testing.T) {
 	}
 }
 
+func TestStructTagAnonymousEmbeddingIsInlined(t *testing.T) {
+	type TypeMeta struct {
+		Kind string `kjson:"kind"`
+	}
+	type ObjectMeta struct {
+		Name string `kjson:"name"`
+	}
+	type Pod struct {
+		TypeMeta
+		ObjectMeta `kjson:"metadata,inline"`
+		Replicas   int `kjson:"replicas"`
+	}
+
+	p := Pod{
+		TypeMeta:   TypeMeta{Kind: "Pod"},
+		ObjectMeta: ObjectMeta{Name: "web"},
+		Replicas:   3,
+	}
+
+	result, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal struct failed: %v", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := Unmarshal(result, &parsed); err != nil {
+		t.Fatalf("Unmarshal to map failed: %v", err)
+	}
+	if _, ok := parsed["metadata"]; ok {
+		t.Errorf("parsed = %v, want ObjectMeta's fields promoted, not nested under %q", parsed, "metadata")
+	}
+	if parsed["kind"] != "Pod" || parsed["name"] != "web" {
+		t.Errorf("parsed = %v, want kind and name promoted to the top level", parsed)
+	}
+
+	var roundTripped Pod
+	if err := Unmarshal(result, &roundTripped); err != nil {
+		t.Fatalf("Unmarshal struct failed: %v", err)
+	}
+	if roundTripped != p {
+		t.Errorf("roundTripped = %+v, want %+v", roundTripped, p)
+	}
+}
+
+func TestStructTagInlineMapCatchesUnknownFields(t *testing.T) {
+	type Resource struct {
+		Name  string                 `kjson:"name"`
+		Extra map[string]interface{} `kjson:",inline"`
+	}
+
+	var r Resource
+	err := Unmarshal([]byte(`{name: "widget", color: "red", weight: 12}`), &r)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if r.Name != "widget" {
+		t.Errorf("Name = %q, want %q", r.Name, "widget")
+	}
+	if r.Extra["color"] != "red" || r.Extra["weight"] != float64(12) {
+		t.Errorf("Extra = %v, want color=red and weight=12", r.Extra)
+	}
+}
+
+func TestStructTagStringOption(t *testing.T) {
+	type Page struct {
+		Size   int  `kjson:"size,string"`
+		Active bool `kjson:"active,string"`
+	}
+
+	p := Page{Size: 50, Active: true}
+	result, err := Marshal(p)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(result), `'50'`) || !strings.Contains(string(result), `'true'`) {
+		t.Errorf("Marshal output = %s, want size and active quoted", result)
+	}
+
+	var parsed Page
+	if err := Unmarshal(result, &parsed); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if parsed != p {
+		t.Errorf("parsed = %+v, want %+v", parsed, p)
+	}
+}
+
 // Test complex object with extended types
 func TestComplexObject(t *testing.T) {
 	type ComplexObject struct {
@@ -269,6 +472,89 @@ func TestUUIDGeneration(t *testing.T) {
 	}
 }
 
+// Test UUIDv1, UUIDv6, and UUIDv8 generation
+func TestUUIDv1v6v8Generation(t *testing.T) {
+	u1 := UUIDv1()
+	if UUIDVersion(u1) != 1 {
+		t.Errorf("UUIDv1 version = %d, want 1", UUIDVersion(u1))
+	}
+
+	u6 := UUIDv6()
+	if UUIDVersion(u6) != 6 {
+		t.Errorf("UUIDv6 version = %d, want 6", UUIDVersion(u6))
+	}
+
+	var custom [16]byte
+	for i := range custom {
+		custom[i] = byte(i)
+	}
+	u8 := UUIDv8(custom)
+	if UUIDVersion(u8) != 8 {
+		t.Errorf("UUIDv8 version = %d, want 8", UUIDVersion(u8))
+	}
+	if u8[7] != custom[7] || u8[15] != custom[15] {
+		t.Errorf("UUIDv8 should preserve custom payload bytes outside the version/variant nibbles")
+	}
+}
+
+// Test that UUIDv6 sorts lexicographically by creation time, unlike UUIDv1.
+func TestUUIDv6SortsByTime(t *testing.T) {
+	millis := []int64{1000, 2000, 3000}
+	gen := &UUIDGenerator{}
+
+	var ids []uuid.UUID
+	for _, ms := range millis {
+		when := time.UnixMilli(ms)
+		gen.Now = func() time.Time { return when }
+		ids = append(ids, gen.UUIDv6())
+	}
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1].String() >= ids[i].String() {
+			t.Errorf("UUIDv6 values are not lexicographically ordered: %s >= %s", ids[i-1], ids[i])
+		}
+	}
+}
+
+// Test that UUIDv7 stays monotonic across calls made within the same
+// millisecond by feeding it a fixed clock.
+func TestUUIDv7MonotonicWithinMillisecond(t *testing.T) {
+	fixed := time.UnixMilli(1_700_000_000_000)
+	gen := &UUIDGenerator{Now: func() time.Time { return fixed }}
+
+	var ids []uuid.UUID
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, gen.UUIDv7())
+	}
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1].String() >= ids[i].String() {
+			t.Fatalf("UUIDv7 values are not monotonic at index %d: %s >= %s", i, ids[i-1], ids[i])
+		}
+	}
+}
+
+// Test that UUIDv7's counter rolls over to the next millisecond instead
+// of wrapping once it saturates.
+func TestUUIDv7CounterRollover(t *testing.T) {
+	fixed := time.UnixMilli(1_700_000_000_000)
+	gen := &UUIDGenerator{
+		Now:     func() time.Time { return fixed },
+		counter: maxV7Counter,
+	}
+	gen.lastMillis = fixed.UnixMilli()
+
+	first := gen.UUIDv7()
+	second := gen.UUIDv7()
+
+	if first.String() >= second.String() {
+		t.Errorf("UUIDv7 did not stay monotonic across a counter rollover: %s >= %s", first, second)
+	}
+	if gen.lastMillis != fixed.UnixMilli()+1 {
+		t.Errorf("UUIDv7 counter rollover did not advance lastMillis: got %d, want %d", gen.lastMillis, fixed.UnixMilli()+1)
+	}
+}
+
 // Test parsing of kJSON string with extended types
 func TestParseExtendedTypes(t *testing.T) {
 	input := `{
@@ -334,11 +620,11 @@ func TestSmartQuoteSelection(t *testing.T) {
 	
 	for _, test := range tests {
 		data := map[string]interface{}{"text": test.input}
-		result, err := Marshal(data)
+		result, err := Marshal(data, WithQuoteStyle(QuoteStyleSmart))
 		if err != nil {
 			t.Fatal(err)
 		}
-		
+
 		expected := "{text:" + test.expected + "}"
 		if string(result) != expected {
 			t.Errorf("Smart quote failed for %q: got %s, want %s", 
@@ -347,6 +633,26 @@ func TestSmartQuoteSelection(t *testing.T) {
 	}
 }
 
+func TestWithQuoteStyleDoubleEscapesEmbeddedQuote(t *testing.T) {
+	result, err := Marshal(`he said "hi"`, WithQuoteStyle(QuoteStyleDouble))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	expected := `"he said \"hi\""`
+	if string(result) != expected {
+		t.Errorf("Marshal output = %q, want %q", result, expected)
+	}
+
+	var got string
+	if err := Unmarshal(result, &got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if got != `he said "hi"` {
+		t.Errorf("round-tripped value = %q, want %q", got, `he said "hi"`)
+	}
+}
+
 func TestMultilineStrings(t *testing.T) {
 	// Test multiline strings with different quote types
 	multiline := "Line 1\nLine 2\nLine 3"
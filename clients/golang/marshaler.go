@@ -0,0 +1,145 @@
+package kjson
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// isBuiltinDestType reports whether t is one of the Go types
+// fromKJsonValueReflect already knows how to populate directly (BigInt,
+// Decimal128, Date, time.Time, uuid.UUID, Number), which should keep using
+// their native kJSON handling instead of being diverted through a
+// user-defined Unmarshaler hook.
+func isBuiltinDestType(t reflect.Type) bool {
+	switch t {
+	case reflect.TypeOf(BigInt{}), reflect.TypeOf(&BigInt{}),
+		reflect.TypeOf(Decimal128{}), reflect.TypeOf(&Decimal128{}),
+		reflect.TypeOf(Date{}), reflect.TypeOf(&Date{}),
+		reflect.TypeOf(time.Time{}), reflect.TypeOf(&time.Time{}),
+		reflect.TypeOf(uuid.UUID{}), reflect.TypeOf(&uuid.UUID{}),
+		reflect.TypeOf(Number("")):
+		return true
+	default:
+		return false
+	}
+}
+
+// KJsonMarshaler is implemented by types that want to produce their own
+// kJSON representation instead of being walked by reflection, mirroring
+// json.Marshaler.
+type KJsonMarshaler interface {
+	MarshalKJSON() (*Value, error)
+}
+
+// KJsonUnmarshaler is implemented by types that want to decode their own
+// kJSON representation instead of being walked by reflection, mirroring
+// json.Unmarshaler.
+type KJsonUnmarshaler interface {
+	UnmarshalKJSON(*Value) error
+}
+
+// marshalCandidates returns rv itself, and rv.Addr() when rv is
+// addressable, as the set of reflect.Values whose method sets should be
+// checked for a marshal/unmarshal hook - covering both value- and
+// pointer-receiver implementations.
+func marshalCandidates(rv reflect.Value) []reflect.Value {
+	candidates := make([]reflect.Value, 0, 2)
+	if rv.CanInterface() {
+		candidates = append(candidates, rv)
+	}
+	if rv.CanAddr() {
+		if addr := rv.Addr(); addr.CanInterface() {
+			candidates = append(candidates, addr)
+		}
+	}
+	return candidates
+}
+
+// marshalViaHook converts rv to a Value using, in priority order, its
+// KJsonMarshaler, json.Marshaler, or encoding.TextMarshaler implementation.
+// ok is false if rv implements none of them, in which case the caller
+// should fall back to reflection.
+func marshalViaHook(rv reflect.Value) (value *Value, ok bool, err error) {
+	if !rv.IsValid() {
+		return nil, false, nil
+	}
+	candidates := marshalCandidates(rv)
+
+	for _, c := range candidates {
+		if m, ok := c.Interface().(KJsonMarshaler); ok {
+			v, err := m.MarshalKJSON()
+			return v, true, err
+		}
+	}
+	for _, c := range candidates {
+		if m, ok := c.Interface().(json.Marshaler); ok {
+			data, err := m.MarshalJSON()
+			if err != nil {
+				return nil, true, err
+			}
+			v, err := parseWithConfig(string(data), nil)
+			return v, true, err
+		}
+	}
+	for _, c := range candidates {
+		if m, ok := c.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			if err != nil {
+				return nil, true, err
+			}
+			return &Value{Type: TypeString, String: string(text)}, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// unmarshalViaHook decodes value into rv using, in priority order, its
+// KJsonUnmarshaler, json.Unmarshaler, or encoding.TextUnmarshaler
+// implementation. ok is false if rv implements none of them, in which
+// case the caller should fall back to reflection.
+func unmarshalViaHook(value *Value, rv reflect.Value) (ok bool, err error) {
+	if !rv.IsValid() {
+		return false, nil
+	}
+	candidates := marshalCandidates(rv)
+
+	for _, c := range candidates {
+		if m, ok := c.Interface().(KJsonUnmarshaler); ok {
+			return true, m.UnmarshalKJSON(value)
+		}
+	}
+	for _, c := range candidates {
+		if m, ok := c.Interface().(json.Unmarshaler); ok {
+			data, err := valueToJSONBytes(value)
+			if err != nil {
+				return true, err
+			}
+			return true, m.UnmarshalJSON(data)
+		}
+	}
+	for _, c := range candidates {
+		if m, ok := c.Interface().(encoding.TextUnmarshaler); ok {
+			if value.Type != TypeString {
+				return true, fmt.Errorf("cannot unmarshal %v into %v via TextUnmarshaler", value.Type, c.Type())
+			}
+			return true, m.UnmarshalText([]byte(value.String))
+		}
+	}
+	return false, nil
+}
+
+// valueToJSONBytes renders value as plain (non-extended) JSON, for handing
+// to a json.Unmarshaler that doesn't know about kJSON's BigInt/Decimal128/
+// UUID/Date literals.
+func valueToJSONBytes(value *Value) ([]byte, error) {
+	goValue, err := valueToGoInterface(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(goValue)
+}
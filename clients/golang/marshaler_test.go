@@ -0,0 +1,149 @@
+package kjson
+
+import (
+	"fmt"
+	"testing"
+)
+
+// kelvin implements KJsonMarshaler/KJsonUnmarshaler, rendering itself as a
+// bare number of degrees.
+type kelvin float64
+
+func (k kelvin) MarshalKJSON() (*Value, error) {
+	return &Value{Type: TypeNumber, Number: float64(k)}, nil
+}
+
+func (k *kelvin) UnmarshalKJSON(v *Value) error {
+	if v.Type != TypeNumber {
+		return fmt.Errorf("kelvin: expected number, got %v", v.Type)
+	}
+	*k = kelvin(v.Number)
+	return nil
+}
+
+func TestKJsonMarshalerRoundTrip(t *testing.T) {
+	data, err := Marshal(kelvin(310.15))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "310.15" {
+		t.Errorf("Marshal output = %q, want %q", data, "310.15")
+	}
+
+	var k kelvin
+	if err := Unmarshal(data, &k); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if k != kelvin(310.15) {
+		t.Errorf("k = %v, want %v", k, kelvin(310.15))
+	}
+}
+
+// priority implements encoding.TextMarshaler/TextUnmarshaler, the kind of
+// type the request body calls out (enum types with String()/UnmarshalText).
+type priority int
+
+const (
+	priorityLow priority = iota
+	priorityHigh
+)
+
+func (p priority) MarshalText() ([]byte, error) {
+	if p == priorityHigh {
+		return []byte("high"), nil
+	}
+	return []byte("low"), nil
+}
+
+func (p *priority) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "high":
+		*p = priorityHigh
+	case "low":
+		*p = priorityLow
+	default:
+		return fmt.Errorf("priority: unknown value %q", text)
+	}
+	return nil
+}
+
+func TestTextMarshalerRoundTrip(t *testing.T) {
+	data, err := Marshal(priorityHigh)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "'high'" {
+		t.Errorf("Marshal output = %q, want %q", data, "'high'")
+	}
+
+	var p priority
+	if err := Unmarshal(data, &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if p != priorityHigh {
+		t.Errorf("p = %v, want %v", p, priorityHigh)
+	}
+}
+
+// rgb implements encoding/json's Marshaler/Unmarshaler, matching the
+// fallback chain's second tier.
+type rgb struct {
+	R, G, B uint8
+}
+
+func (c rgb) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"#%02x%02x%02x"`, c.R, c.G, c.B)), nil
+}
+
+func (c *rgb) UnmarshalJSON(data []byte) error {
+	var hex string
+	if err := unmarshalJSONString(data, &hex); err != nil {
+		return err
+	}
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &r, &g, &b); err != nil {
+		return err
+	}
+	c.R, c.G, c.B = r, g, b
+	return nil
+}
+
+// unmarshalJSONString strips the surrounding double quotes json.Marshal
+// produces for a Go string, without pulling in encoding/json just for this
+// test.
+func unmarshalJSONString(data []byte, out *string) error {
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("not a JSON string: %s", data)
+	}
+	*out = string(data[1 : len(data)-1])
+	return nil
+}
+
+func TestJSONMarshalerRoundTrip(t *testing.T) {
+	data, err := Marshal(rgb{R: 0xde, G: 0xad, B: 0xed})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `'#deaded'` {
+		t.Errorf("Marshal output = %q, want %q", data, `'#deaded'`)
+	}
+
+	var c rgb
+	if err := Unmarshal(data, &c); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if c != (rgb{R: 0xde, G: 0xad, B: 0xed}) {
+		t.Errorf("c = %+v, want %+v", c, rgb{R: 0xde, G: 0xad, B: 0xed})
+	}
+}
+
+func TestBuiltinTypesTakePriorityOverMarshalHooks(t *testing.T) {
+	d := mustNewDecimal128("1.5")
+	data, err := Marshal(d)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "1.5m" {
+		t.Errorf("Marshal output = %q, want %q (KJsonMarshaler hooks must not divert kJSON's native types)", data, "1.5m")
+	}
+}
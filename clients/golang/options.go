@@ -0,0 +1,123 @@
+package kjson
+
+// Option configures the behavior of Marshal and Unmarshal. Options compose
+// left to right: a later Option overrides an earlier one that touches the
+// same setting.
+type Option func(*config)
+
+// config collects every optional knob Marshal and Unmarshal honor. Its
+// zero value reproduces their original, unconfigured behavior.
+type config struct {
+	prefix     string
+	indent     string
+	quoteStyle QuoteStyle
+
+	dateFormat string
+
+	useNumber        bool
+	disallowComments bool
+	maxDepth         int
+
+	bigIntAsString      bool
+	decimal128Precision int
+
+	escapeHTML bool
+
+	canonical bool
+}
+
+// DefaultOptions returns the baseline Option set Marshal and Unmarshal
+// apply when called with no options, so callers can derive a custom
+// profile from it instead of starting from scratch.
+func DefaultOptions() []Option {
+	return []Option{
+		WithQuoteStyle(QuoteStyleSmart),
+	}
+}
+
+// resolveConfig applies DefaultOptions and then opts, in order, to a fresh
+// config.
+func resolveConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range DefaultOptions() {
+		opt(cfg)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithIndent formats Marshal's output across multiple lines using prefix
+// and indent, the same convention as Encoder.SetIndent and json.Indent.
+func WithIndent(prefix, indent string) Option {
+	return func(c *config) {
+		c.prefix = prefix
+		c.indent = indent
+	}
+}
+
+// WithQuoteStyle selects which quote character Marshal uses for strings.
+func WithQuoteStyle(style QuoteStyle) Option {
+	return func(c *config) { c.quoteStyle = style }
+}
+
+// WithDateFormat overrides the time.Format layout Marshal uses for Date
+// values. The default is Date's own String method (time.RFC3339).
+func WithDateFormat(layout string) Option {
+	return func(c *config) { c.dateFormat = layout }
+}
+
+// WithUseNumber causes Unmarshal to decode a number into an interface{} as
+// a Number instead of a float64, preserving the original literal text.
+func WithUseNumber() Option {
+	return func(c *config) { c.useNumber = true }
+}
+
+// WithDisallowComments causes Unmarshal to reject JSON5-style '//' and
+// '/* ... */' comments instead of skipping over them.
+func WithDisallowComments() Option {
+	return func(c *config) { c.disallowComments = true }
+}
+
+// WithMaxDepth limits how many arrays and objects Unmarshal will parse
+// nested inside one another, returning a ParseError instead of recursing
+// past it. n <= 0 means unlimited, the default.
+func WithMaxDepth(n int) Option {
+	return func(c *config) { c.maxDepth = n }
+}
+
+// WithBigIntAsString causes Marshal to encode BigInt values as quoted
+// strings instead of the 'n'-suffixed kJSON literal, for producers that
+// need their output to stay readable by plain JSON parsers.
+func WithBigIntAsString() Option {
+	return func(c *config) { c.bigIntAsString = true }
+}
+
+// WithDecimal128Precision rounds Decimal128 values to n digits after the
+// decimal point when Marshal stringifies them, trading their arbitrary
+// precision for a fixed one. n <= 0 leaves a value's own precision
+// untouched, the default.
+func WithDecimal128Precision(n int) Option {
+	return func(c *config) { c.decimal128Precision = n }
+}
+
+// WithCanonical causes Marshal to produce byte-stable output: object keys
+// are sorted lexicographically (struct fields are treated the same as map
+// keys, rather than keeping declaration order), numbers are formatted
+// without a '+' in their exponent, and quoteStyle is forced to
+// QuoteStyleDouble regardless of any WithQuoteStyle passed earlier. This
+// makes Marshal's output suitable for hashing, signing, or diffing, at the
+// cost of Go's declaration-order field convention.
+func WithCanonical() Option {
+	return func(c *config) {
+		c.canonical = true
+		c.quoteStyle = QuoteStyleDouble
+	}
+}
+
+// MarshalCanonical is Marshal with WithCanonical applied after any opts,
+// so canonical formatting always wins.
+func MarshalCanonical(v interface{}, opts ...Option) ([]byte, error) {
+	return Marshal(v, append(opts, WithCanonical())...)
+}
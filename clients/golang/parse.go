@@ -10,14 +10,72 @@ import (
 	"github.com/google/uuid"
 )
 
-// ParseError represents a parsing error.
+// ParseError represents a parsing error. Offset is the byte offset within
+// the parser's source at the time of the error. Line, Column, and Snippet
+// - the offending line with a caret under the column - are resolved when
+// the ParseError is constructed, so they're readable directly from the
+// struct without calling Error.
 type ParseError struct {
 	Offset int
 	Msg    string
+
+	Line    int
+	Column  int
+	Snippet string
+
+	source   string
+	baseLine int
 }
 
 func (e *ParseError) Error() string {
-	return fmt.Sprintf("kjson parse error at offset %d: %s", e.Offset, e.Msg)
+	e.resolve()
+	if e.source == "" {
+		return fmt.Sprintf("kjson parse error at offset %d: %s", e.Offset, e.Msg)
+	}
+	return fmt.Sprintf("kjson parse error at line %d, column %d: %s\n%s", e.Line, e.Column, e.Msg, e.Snippet)
+}
+
+// resolve computes Line, Column, and Snippet from source on first use.
+func (e *ParseError) resolve() {
+	if e.Snippet != "" || e.source == "" {
+		return
+	}
+
+	lineStart := 0
+	line := 1
+	for i := 0; i < e.Offset && i < len(e.source); i++ {
+		if e.source[i] == '\n' {
+			line++
+			lineStart = i + 1
+		}
+	}
+
+	lineEnd := len(e.source)
+	if idx := strings.IndexByte(e.source[lineStart:], '\n'); idx >= 0 {
+		lineEnd = lineStart + idx
+	}
+
+	e.Line = e.baseLine + line
+	e.Column = e.Offset - lineStart + 1
+	e.Snippet = e.source[lineStart:lineEnd] + "\n" + strings.Repeat(" ", e.Column-1) + "^"
+}
+
+// ParseErrors wraps multiple ParseErrors found in one parsing pass. Today
+// parse and Decoder stop at the first error, but this gives a future
+// recovering parser somewhere to collect more than one.
+type ParseErrors struct {
+	Errors []*ParseError
+}
+
+func (e *ParseErrors) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d kjson parse errors:\n%s", len(e.Errors), strings.Join(msgs, "\n"))
 }
 
 // Parser state
@@ -25,44 +83,124 @@ type parser struct {
 	data   string
 	offset int
 	length int
+
+	// fill, when non-nil, pulls more bytes into data once the parser has
+	// consumed everything it currently holds. It returns false once the
+	// underlying source is exhausted. Only Decoder sets this; parse(string)
+	// leaves it nil so atEnd degenerates to a plain bounds check.
+	fill func() bool
+
+	// useNumber, when set, causes parseNumber to preserve the original
+	// literal in Value.Raw instead of rounding it through float64.
+	useNumber bool
+
+	// disallowComments, when set, turns off skipWhitespace's JSON5-style
+	// '//' and '/* ... */' comment handling for strict-JSON mode.
+	disallowComments bool
+
+	// baseLine is the number of newlines consumed from the source before
+	// the start of data. It is always 0 for parse(string); Decoder
+	// advances it as it drops already-parsed bytes from its window, so
+	// ParseErrors still report the right line number in a long stream.
+	baseLine int
+
+	// maxDepth, when positive, caps how many arrays and objects may be
+	// nested; depth tracks how many are currently open. maxDepth <= 0
+	// means unlimited, the default.
+	maxDepth int
+	depth    int
+}
+
+// newError builds a ParseError for msg at offset, resolving its Line,
+// Column, and Snippet immediately from the parser's current source window
+// so they're observable on the struct without formatting the message.
+func (p *parser) newError(offset int, msg string) *ParseError {
+	e := &ParseError{Offset: offset, Msg: msg, source: p.data, baseLine: p.baseLine}
+	e.resolve()
+	return e
+}
+
+// enterContainer records entry into a nested array or object, returning a
+// ParseError once maxDepth (when set) is exceeded.
+func (p *parser) enterContainer() error {
+	p.depth++
+	if p.maxDepth > 0 && p.depth > p.maxDepth {
+		return p.newError(p.offset, fmt.Sprintf("max depth %d exceeded", p.maxDepth))
+	}
+	return nil
+}
+
+// exitContainer undoes a prior enterContainer.
+func (p *parser) exitContainer() {
+	p.depth--
 }
 
-// parse parses a kJSON string and returns a Value.
+// parse parses a kJSON string and returns a Value, using the package's
+// default, unconfigured behavior.
 func parse(data string) (*Value, error) {
+	return parseWithConfig(data, nil)
+}
+
+// parseWithConfig is parse with a resolved Option config applied to the
+// parser. A nil cfg reproduces parse's original behavior.
+func parseWithConfig(data string, cfg *config) (*Value, error) {
 	p := &parser{
 		data:   data,
 		offset: 0,
 		length: len(data),
 	}
-	
-	p.skipWhitespace()
-	if p.offset >= p.length {
-		return nil, &ParseError{p.offset, "unexpected end of input"}
+	if cfg != nil {
+		p.useNumber = cfg.useNumber
+		p.disallowComments = cfg.disallowComments
+		p.maxDepth = cfg.maxDepth
 	}
-	
+
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if p.atEnd() {
+		return nil, p.newError(p.offset, "unexpected end of input")
+	}
+
 	value, err := p.parseValue()
 	if err != nil {
 		return nil, err
 	}
-	
-	p.skipWhitespace()
-	if p.offset < p.length {
-		return nil, &ParseError{p.offset, "unexpected characters after kJSON value"}
+
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if !p.atEnd() {
+		return nil, p.newError(p.offset, "unexpected characters after kJSON value")
 	}
-	
+
 	return value, nil
 }
 
+// atEnd reports whether the parser has no more data to read. In streaming
+// mode (fill set), it first tries to pull in another chunk from the
+// underlying io.Reader before declaring the input exhausted.
+func (p *parser) atEnd() bool {
+	for p.offset >= p.length {
+		if p.fill == nil || !p.fill() {
+			return true
+		}
+	}
+	return false
+}
+
 // parseValue parses any kJSON value.
 func (p *parser) parseValue() (*Value, error) {
-	p.skipWhitespace()
-	
-	if p.offset >= p.length {
-		return nil, &ParseError{p.offset, "unexpected end of input"}
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+
+	if p.atEnd() {
+		return nil, p.newError(p.offset, "unexpected end of input")
 	}
-	
+
 	c := p.data[p.offset]
-	
+
 	switch c {
 	case 'n':
 		return p.parseNull()
@@ -79,7 +217,20 @@ func (p *parser) parseValue() (*Value, error) {
 		return p.parseArray()
 	case '{':
 		return p.parseObject()
+	case 'N':
+		if p.hasPrefixAt(p.offset, "NaN") {
+			return p.parseDecimal128Special(false, "NaN")
+		}
+		return p.parseUnquotedLiteral()
+	case 'I':
+		if p.hasPrefixAt(p.offset, "Infinity") {
+			return p.parseDecimal128Special(false, "Infinity")
+		}
+		return p.parseUnquotedLiteral()
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		if c == '-' && p.hasPrefixAt(p.offset+1, "Infinity") {
+			return p.parseDecimal128Special(true, "Infinity")
+		}
 		// Check if this might be a UUID or Date first
 		if value, err := p.tryParseUnquotedLiteral(); err == nil {
 			return value, nil
@@ -94,8 +245,8 @@ func (p *parser) parseValue() (*Value, error) {
 
 // parseNull parses a null value.
 func (p *parser) parseNull() (*Value, error) {
-	if p.offset+4 > p.length || p.data[p.offset:p.offset+4] != "null" {
-		return nil, &ParseError{p.offset, "invalid null value"}
+	if !p.require(4) || p.data[p.offset:p.offset+4] != "null" {
+		return nil, p.newError(p.offset, "invalid null value")
 	}
 	p.offset += 4
 	return &Value{Type: TypeNull}, nil
@@ -103,28 +254,54 @@ func (p *parser) parseNull() (*Value, error) {
 
 // parseBool parses a boolean value.
 func (p *parser) parseBool() (*Value, error) {
-	if p.offset+4 <= p.length && p.data[p.offset:p.offset+4] == "true" {
+	if p.require(4) && p.data[p.offset:p.offset+4] == "true" {
 		p.offset += 4
 		return &Value{Type: TypeBool, Bool: true}, nil
 	}
-	if p.offset+5 <= p.length && p.data[p.offset:p.offset+5] == "false" {
+	if p.require(5) && p.data[p.offset:p.offset+5] == "false" {
 		p.offset += 5
 		return &Value{Type: TypeBool, Bool: false}, nil
 	}
-	return nil, &ParseError{p.offset, "invalid boolean value"}
+	return nil, p.newError(p.offset, "invalid boolean value")
+}
+
+// require ensures at least n bytes are available starting at the current
+// offset, pulling in more streamed data if necessary.
+func (p *parser) require(n int) bool {
+	return p.requireAt(p.offset, n)
+}
+
+// requireAt ensures at least n bytes are available starting at offset,
+// pulling in more streamed data if necessary.
+func (p *parser) requireAt(offset, n int) bool {
+	for offset+n > p.length {
+		if p.fill == nil || !p.fill() {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefixAt reports whether literal appears at offset in the parser's
+// data, pulling in more streamed data if necessary.
+func (p *parser) hasPrefixAt(offset int, literal string) bool {
+	if !p.requireAt(offset, len(literal)) {
+		return false
+	}
+	return p.data[offset:offset+len(literal)] == literal
 }
 
 // parseString parses a quoted string with single quotes, double quotes, or backticks.
 func (p *parser) parseString() (*Value, error) {
 	quote := p.data[p.offset]
 	if quote != '"' && quote != '\'' && quote != '`' {
-		return nil, &ParseError{p.offset, "expected quote character"}
+		return nil, p.newError(p.offset, "expected quote character")
 	}
-	
+
 	start := p.offset + 1
 	p.offset++
-	
-	for p.offset < p.length {
+
+	for !p.atEnd() {
 		c := p.data[p.offset]
 		if c == quote {
 			str := p.data[start:p.offset]
@@ -137,13 +314,16 @@ func (p *parser) parseString() (*Value, error) {
 			return &Value{Type: TypeString, String: unescaped}, nil
 		}
 		if c == '\\' {
-			p.offset += 2 // Skip escape sequence
+			p.offset++
+			if !p.atEnd() {
+				p.offset++
+			}
 		} else {
 			p.offset++
 		}
 	}
-	
-	return nil, &ParseError{start-1, "unterminated string"}
+
+	return nil, p.newError(start-1, "unterminated string")
 }
 
 // unescapeString unescapes a string based on the quote character used.
@@ -156,7 +336,7 @@ func (p *parser) unescapeString(s string, quote byte) (string, error) {
 	s = strings.ReplaceAll(s, "\\n", "\n")
 	s = strings.ReplaceAll(s, "\\r", "\r")
 	s = strings.ReplaceAll(s, "\\t", "\t")
-	
+
 	// Handle quote-specific escapes
 	switch quote {
 	case '"':
@@ -166,73 +346,93 @@ func (p *parser) unescapeString(s string, quote byte) (string, error) {
 	case '`':
 		s = strings.ReplaceAll(s, "\\`", "`")
 	}
-	
+
 	return s, nil
 }
 
 // parseArray parses an array.
 func (p *parser) parseArray() (*Value, error) {
 	if p.data[p.offset] != '[' {
-		return nil, &ParseError{p.offset, "expected '['"}
+		return nil, p.newError(p.offset, "expected '['")
 	}
 	p.offset++
-	
+
+	if err := p.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer p.exitContainer()
+
 	var items []*Value
-	
-	p.skipWhitespace()
-	if p.offset < p.length && p.data[p.offset] == ']' {
+
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if !p.atEnd() && p.data[p.offset] == ']' {
 		p.offset++
 		return &Value{Type: TypeArray, Array: items}, nil
 	}
-	
+
 	for {
 		item, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
 		items = append(items, item)
-		
-		p.skipWhitespace()
-		if p.offset >= p.length {
-			return nil, &ParseError{p.offset, "unterminated array"}
+
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.atEnd() {
+			return nil, p.newError(p.offset, "unterminated array")
 		}
-		
+
 		c := p.data[p.offset]
 		if c == ']' {
 			p.offset++
 			break
 		} else if c == ',' {
 			p.offset++
-			p.skipWhitespace()
+			if err := p.skipWhitespace(); err != nil {
+				return nil, err
+			}
 		} else {
-			return nil, &ParseError{p.offset, "expected ',' or ']'"}
+			return nil, p.newError(p.offset, "expected ',' or ']'")
 		}
 	}
-	
+
 	return &Value{Type: TypeArray, Array: items}, nil
 }
 
 // parseObject parses an object.
 func (p *parser) parseObject() (*Value, error) {
 	if p.data[p.offset] != '{' {
-		return nil, &ParseError{p.offset, "expected '{'"}
+		return nil, p.newError(p.offset, "expected '{'")
 	}
 	p.offset++
-	
+
+	if err := p.enterContainer(); err != nil {
+		return nil, err
+	}
+	defer p.exitContainer()
+
 	obj := make(map[string]*Value)
-	
-	p.skipWhitespace()
-	if p.offset < p.length && p.data[p.offset] == '}' {
+
+	if err := p.skipWhitespace(); err != nil {
+		return nil, err
+	}
+	if !p.atEnd() && p.data[p.offset] == '}' {
 		p.offset++
 		return &Value{Type: TypeObject, Object: obj}, nil
 	}
-	
+
 	for {
 		// Parse key
-		p.skipWhitespace()
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
 		var key string
-		
-		if p.offset < p.length && (p.data[p.offset] == '"' || p.data[p.offset] == '\'' || p.data[p.offset] == '`') {
+
+		if !p.atEnd() && (p.data[p.offset] == '"' || p.data[p.offset] == '\'' || p.data[p.offset] == '`') {
 			// Quoted key
 			keyValue, err := p.parseString()
 			if err != nil {
@@ -247,152 +447,160 @@ func (p *parser) parseObject() (*Value, error) {
 				return nil, err
 			}
 		}
-		
+
 		// Parse colon
-		p.skipWhitespace()
-		if p.offset >= p.length || p.data[p.offset] != ':' {
-			return nil, &ParseError{p.offset, "expected ':' after object key"}
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.atEnd() || p.data[p.offset] != ':' {
+			return nil, p.newError(p.offset, "expected ':' after object key")
 		}
 		p.offset++
-		
+
 		// Parse value
 		value, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
-		
+
 		obj[key] = value
-		
-		p.skipWhitespace()
-		if p.offset >= p.length {
-			return nil, &ParseError{p.offset, "unterminated object"}
+
+		if err := p.skipWhitespace(); err != nil {
+			return nil, err
+		}
+		if p.atEnd() {
+			return nil, p.newError(p.offset, "unterminated object")
 		}
-		
+
 		c := p.data[p.offset]
 		if c == '}' {
 			p.offset++
 			break
 		} else if c == ',' {
 			p.offset++
-			p.skipWhitespace()
+			if err := p.skipWhitespace(); err != nil {
+				return nil, err
+			}
 		} else {
-			return nil, &ParseError{p.offset, "expected ',' or '}'"}
+			return nil, p.newError(p.offset, "expected ',' or '}'")
 		}
 	}
-	
+
 	return &Value{Type: TypeObject, Object: obj}, nil
 }
 
 // parseUnquotedKey parses an unquoted object key (JSON5 style).
 func (p *parser) parseUnquotedKey() (string, error) {
 	start := p.offset
-	
-	if p.offset >= p.length {
-		return "", &ParseError{p.offset, "expected object key"}
+
+	if p.atEnd() {
+		return "", p.newError(p.offset, "expected object key")
 	}
-	
+
 	// First character must be letter, underscore, or dollar sign
 	c := rune(p.data[p.offset])
 	if !unicode.IsLetter(c) && c != '_' && c != '$' {
-		return "", &ParseError{p.offset, "invalid unquoted key"}
+		return "", p.newError(p.offset, "invalid unquoted key")
 	}
-	
+
 	p.offset++
-	
+
 	// Subsequent characters can be letters, digits, underscores, or dollar signs
-	for p.offset < p.length {
+	for !p.atEnd() {
 		c := rune(p.data[p.offset])
 		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '$' {
 			break
 		}
 		p.offset++
 	}
-	
+
 	return p.data[start:p.offset], nil
 }
 
 // parseNumber parses a number (regular number, BigInt, or Decimal128).
 func (p *parser) parseNumber() (*Value, error) {
 	start := p.offset
-	
+
 	// Skip negative sign
-	if p.offset < p.length && p.data[p.offset] == '-' {
+	if !p.atEnd() && p.data[p.offset] == '-' {
 		p.offset++
 	}
-	
+
 	// Parse digits
-	if p.offset >= p.length || !isDigit(p.data[p.offset]) {
-		return nil, &ParseError{p.offset, "invalid number"}
+	if p.atEnd() || !isDigit(p.data[p.offset]) {
+		return nil, p.newError(p.offset, "invalid number")
 	}
-	
+
 	// Parse integer part
 	if p.data[p.offset] == '0' {
 		p.offset++
 	} else {
-		for p.offset < p.length && isDigit(p.data[p.offset]) {
+		for !p.atEnd() && isDigit(p.data[p.offset]) {
 			p.offset++
 		}
 	}
-	
+
 	// Check for decimal point
-	hasDecimal := false
-	if p.offset < p.length && p.data[p.offset] == '.' {
-		hasDecimal = true
+	if !p.atEnd() && p.data[p.offset] == '.' {
 		p.offset++
-		if p.offset >= p.length || !isDigit(p.data[p.offset]) {
-			return nil, &ParseError{p.offset, "invalid decimal number"}
+		if p.atEnd() || !isDigit(p.data[p.offset]) {
+			return nil, p.newError(p.offset, "invalid decimal number")
 		}
-		for p.offset < p.length && isDigit(p.data[p.offset]) {
+		for !p.atEnd() && isDigit(p.data[p.offset]) {
 			p.offset++
 		}
 	}
-	
+
 	// Check for exponent
-	hasExponent := false
-	if p.offset < p.length && (p.data[p.offset] == 'e' || p.data[p.offset] == 'E') {
-		hasExponent = true
+	if !p.atEnd() && (p.data[p.offset] == 'e' || p.data[p.offset] == 'E') {
 		p.offset++
-		if p.offset < p.length && (p.data[p.offset] == '+' || p.data[p.offset] == '-') {
+		if !p.atEnd() && (p.data[p.offset] == '+' || p.data[p.offset] == '-') {
 			p.offset++
 		}
-		if p.offset >= p.length || !isDigit(p.data[p.offset]) {
-			return nil, &ParseError{p.offset, "invalid exponent"}
+		if p.atEnd() || !isDigit(p.data[p.offset]) {
+			return nil, p.newError(p.offset, "invalid exponent")
 		}
-		for p.offset < p.length && isDigit(p.data[p.offset]) {
+		for !p.atEnd() && isDigit(p.data[p.offset]) {
 			p.offset++
 		}
 	}
-	
+
 	// Check for BigInt suffix
-	if p.offset < p.length && p.data[p.offset] == 'n' {
+	if !p.atEnd() && p.data[p.offset] == 'n' {
 		p.offset++
 		numStr := p.data[start:p.offset-1]
 		return p.parseBigInt(numStr)
 	}
-	
+
 	// Check for Decimal128 suffix
-	if p.offset < p.length && p.data[p.offset] == 'm' {
+	if !p.atEnd() && p.data[p.offset] == 'm' {
 		p.offset++
 		numStr := p.data[start:p.offset-1]
 		return p.parseDecimal128(numStr)
 	}
-	
+
+	// Check for a third-party type's literal suffix, registered with
+	// RegisterTypeSuffix.
+	if !p.atEnd() {
+		if rt, ok := lookupTypeBySuffix(p.data[p.offset]); ok {
+			numStr := p.data[start:p.offset]
+			p.offset++
+			return &Value{Type: TypeExtended, Extended: &ExtendedValue{Name: rt.name, Raw: numStr}}, nil
+		}
+	}
+
 	// Regular number
 	numStr := p.data[start:p.offset]
-	if hasDecimal || hasExponent {
-		f, err := strconv.ParseFloat(numStr, 64)
-		if err != nil {
-			return nil, &ParseError{start, "invalid number: "+err.Error()}
-		}
-		return &Value{Type: TypeNumber, Number: f}, nil
-	} else {
-		// Integer - try to fit in float64
-		f, err := strconv.ParseFloat(numStr, 64)
-		if err != nil {
-			return nil, &ParseError{start, "invalid number: "+err.Error()}
-		}
-		return &Value{Type: TypeNumber, Number: f}, nil
+
+	if p.useNumber {
+		return &Value{Type: TypeNumber, Raw: Number(numStr)}, nil
+	}
+
+	f, err := strconv.ParseFloat(numStr, 64)
+	if err != nil {
+		return nil, p.newError(start, "invalid number: "+err.Error())
 	}
+	return &Value{Type: TypeNumber, Number: f}, nil
 }
 
 // parseBigInt parses a BigInt from a number string.
@@ -402,14 +610,14 @@ func (p *parser) parseBigInt(numStr string) (*Value, error) {
 		negative = true
 		numStr = numStr[1:]
 	}
-	
+
 	// Validate digits
 	for _, c := range numStr {
 		if !isDigit(byte(c)) {
-			return nil, &ParseError{p.offset, "invalid BigInt"}
+			return nil, p.newError(p.offset, "invalid BigInt")
 		}
 	}
-	
+
 	return &Value{
 		Type: TypeBigInt,
 		BigInt: &BigInt{
@@ -423,15 +631,36 @@ func (p *parser) parseBigInt(numStr string) (*Value, error) {
 func (p *parser) parseDecimal128(numStr string) (*Value, error) {
 	d, err := NewDecimal128(numStr)
 	if err != nil {
-		return nil, &ParseError{p.offset, "invalid Decimal128: "+err.Error()}
+		return nil, p.newError(p.offset, "invalid Decimal128: "+err.Error())
 	}
-	
+
 	return &Value{
 		Type:    TypeDecimal128,
 		Decimal: d,
 	}, nil
 }
 
+// parseDecimal128Special parses a bare "NaN", "Infinity", or "-Infinity"
+// literal into a Decimal128 value, consuming an optional trailing 'm' to
+// match the suffix ordinary Decimal128 literals use.
+func (p *parser) parseDecimal128Special(negative bool, word string) (*Value, error) {
+	if negative {
+		p.offset++ // consume the leading '-'
+	}
+	p.offset += len(word)
+	if !p.atEnd() && p.data[p.offset] == 'm' {
+		p.offset++
+	}
+
+	var d *Decimal128
+	if word == "NaN" {
+		d = Decimal128NaN()
+	} else {
+		d = Decimal128Inf(negative)
+	}
+	return &Value{Type: TypeDecimal128, Decimal: d}, nil
+}
+
 // tryParseUnquotedLiteral attempts to parse unquoted literals without advancing on failure.
 func (p *parser) tryParseUnquotedLiteral() (*Value, error) {
 	savedOffset := p.offset
@@ -446,49 +675,79 @@ func (p *parser) tryParseUnquotedLiteral() (*Value, error) {
 // parseUnquotedLiteral parses unquoted literals (UUID, Date).
 func (p *parser) parseUnquotedLiteral() (*Value, error) {
 	start := p.offset
-	
+
 	// Read until whitespace or delimiter
 	// Don't stop at ':' for value parsing (needed for ISO dates)
-	for p.offset < p.length {
+	for !p.atEnd() {
 		c := p.data[p.offset]
 		if isWhitespace(c) || c == ',' || c == ']' || c == '}' {
 			break
 		}
 		p.offset++
 	}
-	
+
 	literal := p.data[start:p.offset]
-	
+
 	// Try to parse as UUID
 	if u, err := uuid.Parse(literal); err == nil {
 		return &Value{Type: TypeUUID, UUID: u}, nil
 	}
-	
+
 	// Try to parse as Date (ISO 8601)
 	if t, err := time.Parse(time.RFC3339, literal); err == nil {
 		return &Value{Type: TypeDate, Date: NewDate(t)}, nil
 	}
-	
+
 	// Try other time formats
 	timeFormats := []string{
 		time.RFC3339Nano,
 		"2006-01-02T15:04:05Z",
 		"2006-01-02T15:04:05.000Z",
 	}
-	
+
 	for _, format := range timeFormats {
 		if t, err := time.Parse(format, literal); err == nil {
 			return &Value{Type: TypeDate, Date: NewDate(t)}, nil
 		}
 	}
-	
-	return nil, &ParseError{start, "invalid literal: "+literal}
+
+	return nil, p.newError(start, "invalid literal: "+literal)
 }
 
-// skipWhitespace skips whitespace characters.
-func (p *parser) skipWhitespace() {
-	for p.offset < p.length && isWhitespace(p.data[p.offset]) {
-		p.offset++
+// skipWhitespace skips whitespace characters and, unless disallowComments
+// is set, JSON5-style '//' line comments and '/* ... */' block comments.
+func (p *parser) skipWhitespace() error {
+	for {
+		for !p.atEnd() && isWhitespace(p.data[p.offset]) {
+			p.offset++
+		}
+
+		if p.disallowComments || p.atEnd() || p.data[p.offset] != '/' || !p.require(2) {
+			return nil
+		}
+
+		switch p.data[p.offset+1] {
+		case '/':
+			p.offset += 2
+			for !p.atEnd() && p.data[p.offset] != '\n' {
+				p.offset++
+			}
+		case '*':
+			start := p.offset
+			p.offset += 2
+			for {
+				if !p.require(2) {
+					return p.newError(start, "unterminated block comment")
+				}
+				if p.data[p.offset] == '*' && p.data[p.offset+1] == '/' {
+					p.offset += 2
+					break
+				}
+				p.offset++
+			}
+		default:
+			return nil
+		}
 	}
 }
 
@@ -500,4 +759,4 @@ func isWhitespace(c byte) bool {
 // isDigit checks if a character is a digit.
 func isDigit(c byte) bool {
 	return c >= '0' && c <= '9'
-}
\ No newline at end of file
+}
@@ -0,0 +1,30 @@
+package kjson
+
+import (
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ToProto converts i to a *timestamppb.Timestamp for interop with gRPC
+// services built on google.protobuf.Timestamp.
+func (i *Instant) ToProto() *timestamppb.Timestamp {
+	return timestamppb.New(i.ToTime())
+}
+
+// InstantFromProto converts a *timestamppb.Timestamp to an Instant,
+// returning ErrInstantRangeOverflow if it falls outside the valid
+// Instant range.
+func InstantFromProto(ts *timestamppb.Timestamp) (*Instant, error) {
+	return NewInstantFromTime(ts.AsTime())
+}
+
+// ToProto converts d to a *durationpb.Duration for interop with gRPC
+// services built on google.protobuf.Duration.
+func (d *Duration) ToProto() *durationpb.Duration {
+	return durationpb.New(d.ToGoDuration())
+}
+
+// DurationFromProto converts a *durationpb.Duration to a Duration.
+func DurationFromProto(d *durationpb.Duration) *Duration {
+	return NewDurationFromGoDuration(d.AsDuration())
+}
@@ -0,0 +1,130 @@
+package kjson
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TypeCodec converts between a Go value and a kJSON Value for a
+// third-party type registered with RegisterType, so types like net.IP,
+// big.Rat, shopspring/decimal.Decimal, or civil.Date can round-trip
+// through Marshal/Unmarshal without kJSON special-casing them the way
+// BigInt, Decimal128, UUID, and Date already are.
+type TypeCodec interface {
+	// Marshal encodes v, a value of the registered Go type, to a Value.
+	Marshal(v reflect.Value) (*Value, error)
+	// Unmarshal decodes val into v, a settable reflect.Value of the
+	// registered Go type.
+	Unmarshal(val *Value, v reflect.Value) error
+}
+
+// ExtendedValue carries the literal text and registered type name for a
+// TypeExtended Value: a bare literal the parser recognized via a suffix
+// registered with RegisterTypeSuffix, but that has no native kJSON
+// ValueType. Unmarshal resolves it through the name's registered
+// TypeCodec.
+type ExtendedValue struct {
+	Name string
+	Raw  string
+}
+
+// registeredType is one RegisterType entry, along with the literal
+// suffix RegisterTypeSuffix may have associated with it.
+type registeredType struct {
+	name   string
+	goType reflect.Type
+	suffix byte // 0 until RegisterTypeSuffix claims one
+	codec  TypeCodec
+}
+
+var typeRegistry = struct {
+	mu       sync.RWMutex
+	byGoType map[reflect.Type]*registeredType
+	byName   map[string]*registeredType
+	bySuffix map[byte]*registeredType
+}{
+	byGoType: make(map[reflect.Type]*registeredType),
+	byName:   make(map[string]*registeredType),
+	bySuffix: make(map[byte]*registeredType),
+}
+
+// RegisterType adds codec as the encoder/decoder kJSON uses for goType,
+// under name. Marshal consults the registry by Go type before falling
+// back to reflection, and Unmarshal consults it by name when decoding a
+// TypeExtended value. Registering the same Go type or name again
+// replaces the earlier entry.
+func RegisterType(name string, goType reflect.Type, codec TypeCodec) {
+	typeRegistry.mu.Lock()
+	defer typeRegistry.mu.Unlock()
+
+	rt := &registeredType{name: name, goType: goType, codec: codec}
+	if existing, ok := typeRegistry.byName[name]; ok {
+		rt.suffix = existing.suffix
+		if rt.suffix != 0 {
+			typeRegistry.bySuffix[rt.suffix] = rt
+		}
+	}
+	typeRegistry.byGoType[goType] = rt
+	typeRegistry.byName[name] = rt
+}
+
+// RegisterTypeSuffix tells the parser to recognize a bare number literal
+// ending in suffix as name's registered type, the same mechanism kJSON's
+// own BigInt ('n') and Decimal128 ('m') literals already use; words such
+// as NaN or Infinity followed by suffix are not recognized. RegisterType
+// must be called for name first, and
+// suffix must not already be claimed by 'n', 'm', or another registered
+// type.
+func RegisterTypeSuffix(name string, suffix byte) error {
+	if suffix == 'n' || suffix == 'm' {
+		return fmt.Errorf("kjson: RegisterTypeSuffix: suffix %q is reserved for BigInt/Decimal128", suffix)
+	}
+
+	typeRegistry.mu.Lock()
+	defer typeRegistry.mu.Unlock()
+
+	old, ok := typeRegistry.byName[name]
+	if !ok {
+		return fmt.Errorf("kjson: RegisterTypeSuffix: type %q is not registered", name)
+	}
+	if existing, ok := typeRegistry.bySuffix[suffix]; ok && existing.name != name {
+		return fmt.Errorf("kjson: RegisterTypeSuffix: suffix %q is already claimed by %q", suffix, existing.name)
+	}
+
+	// Build a new entry rather than mutating old in place: a concurrent
+	// Marshal/Unmarshal may be holding old via an earlier lookup, under
+	// only a read lock.
+	rt := &registeredType{name: old.name, goType: old.goType, codec: old.codec, suffix: suffix}
+	if old.suffix != 0 && old.suffix != suffix {
+		delete(typeRegistry.bySuffix, old.suffix)
+	}
+	typeRegistry.byName[name] = rt
+	typeRegistry.byGoType[rt.goType] = rt
+	typeRegistry.bySuffix[suffix] = rt
+	return nil
+}
+
+// lookupTypeByGoType returns name's registered entry for goType, if any.
+func lookupTypeByGoType(t reflect.Type) (*registeredType, bool) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+	rt, ok := typeRegistry.byGoType[t]
+	return rt, ok
+}
+
+// lookupTypeByName returns the registered entry added under name, if any.
+func lookupTypeByName(name string) (*registeredType, bool) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+	rt, ok := typeRegistry.byName[name]
+	return rt, ok
+}
+
+// lookupTypeBySuffix returns the registered entry claiming suffix, if any.
+func lookupTypeBySuffix(suffix byte) (*registeredType, bool) {
+	typeRegistry.mu.RLock()
+	defer typeRegistry.mu.RUnlock()
+	rt, ok := typeRegistry.bySuffix[suffix]
+	return rt, ok
+}
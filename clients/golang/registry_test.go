@@ -0,0 +1,114 @@
+package kjson
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fraction is a third-party-shaped type registered through RegisterType
+// rather than implementing KJsonMarshaler itself, the way net.IP or
+// big.Rat would be wired up without forking the package.
+type fraction struct {
+	Num, Den int
+}
+
+type fractionCodec struct{}
+
+func (fractionCodec) Marshal(v reflect.Value) (*Value, error) {
+	f := v.Interface().(fraction)
+	return &Value{Type: TypeString, String: fmt.Sprintf("%d/%d", f.Num, f.Den)}, nil
+}
+
+func (fractionCodec) Unmarshal(val *Value, v reflect.Value) error {
+	if val.Type != TypeString {
+		return fmt.Errorf("fraction: expected string, got %v", val.Type)
+	}
+	num, den, ok := strings.Cut(val.String, "/")
+	if !ok {
+		return fmt.Errorf("fraction: invalid literal %q", val.String)
+	}
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return err
+	}
+	d, err := strconv.Atoi(den)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(fraction{Num: n, Den: d}))
+	return nil
+}
+
+func TestRegisterTypeRoundTrip(t *testing.T) {
+	RegisterType("fraction", reflect.TypeOf(fraction{}), fractionCodec{})
+
+	data, err := Marshal(fraction{Num: 3, Den: 4})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "'3/4'" {
+		t.Errorf("Marshal output = %q, want %q", data, "'3/4'")
+	}
+
+	var f fraction
+	if err := Unmarshal(data, &f); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if f != (fraction{Num: 3, Den: 4}) {
+		t.Errorf("f = %+v, want %+v", f, fraction{Num: 3, Den: 4})
+	}
+}
+
+// ratio is registered with a literal suffix, so 3r parses as a bare
+// extended-type literal the way 5n parses as a BigInt.
+type ratio int
+
+type ratioCodec struct{}
+
+func (ratioCodec) Marshal(v reflect.Value) (*Value, error) {
+	return &Value{Type: TypeExtended, Extended: &ExtendedValue{
+		Name: "ratio",
+		Raw:  strconv.Itoa(int(v.Interface().(ratio))),
+	}}, nil
+}
+
+func (ratioCodec) Unmarshal(val *Value, v reflect.Value) error {
+	n, err := strconv.Atoi(val.Extended.Raw)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(ratio(n)))
+	return nil
+}
+
+func TestRegisterTypeSuffixRoundTrip(t *testing.T) {
+	RegisterType("ratio", reflect.TypeOf(ratio(0)), ratioCodec{})
+	if err := RegisterTypeSuffix("ratio", 'r'); err != nil {
+		t.Fatalf("RegisterTypeSuffix failed: %v", err)
+	}
+
+	data, err := Marshal(ratio(3))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != "3r" {
+		t.Errorf("Marshal output = %q, want %q", data, "3r")
+	}
+
+	var r ratio
+	if err := Unmarshal(data, &r); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if r != ratio(3) {
+		t.Errorf("r = %v, want %v", r, ratio(3))
+	}
+}
+
+func TestRegisterTypeSuffixRejectsReservedBytes(t *testing.T) {
+	if err := RegisterTypeSuffix("ratio", 'n'); err == nil {
+		t.Error("RegisterTypeSuffix succeeded, want an error claiming BigInt's reserved 'n' suffix")
+	}
+}
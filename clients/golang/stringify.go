@@ -1,8 +1,10 @@
 package kjson
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,44 +12,146 @@ import (
 	"github.com/google/uuid"
 )
 
-// stringify converts a Value to kJSON bytes.
-func stringify(value *Value) ([]byte, error) {
-	s, err := stringifyValue(value)
-	if err != nil {
+// encodeState accumulates kJSON output in a single growing buffer as it
+// walks a Value tree, so nested arrays and objects write their bytes once
+// instead of building intermediate strings that get copied again by every
+// enclosing strings.Join - an allocation pattern that turns quadratic on
+// large documents.
+type encodeState struct {
+	bytes.Buffer
+	cfg *config
+}
+
+// newEncodeState returns an encodeState ready to encode into, using cfg's
+// quote style, indentation, date format, and BigInt/Decimal128 rendering.
+// A nil cfg uses smart quote selection with no indentation, same as before
+// Option existed.
+func newEncodeState(cfg *config) *encodeState {
+	return &encodeState{cfg: cfg}
+}
+
+// stringify converts a Value to kJSON bytes using a resolved Option
+// config. A nil cfg reproduces the package's original, unconfigured
+// behavior.
+func stringify(value *Value, cfg *config) ([]byte, error) {
+	e := newEncodeState(cfg)
+	if err := e.encodeValue(value, 0); err != nil {
 		return nil, err
 	}
-	return []byte(s), nil
+	return e.Bytes(), nil
 }
 
-// stringifyValue converts a Value to kJSON string.
-func stringifyValue(value *Value) (string, error) {
+// encodeValue writes a Value's kJSON text form at the given nesting depth.
+func (e *encodeState) encodeValue(value *Value, depth int) error {
 	switch value.Type {
 	case TypeNull:
-		return "null", nil
+		e.WriteString("null")
 	case TypeBool:
 		if value.Bool {
-			return "true", nil
+			e.WriteString("true")
+		} else {
+			e.WriteString("false")
 		}
-		return "false", nil
 	case TypeNumber:
-		return strconv.FormatFloat(value.Number, 'g', -1, 64), nil
+		canonical := e.cfg != nil && e.cfg.canonical
+		if value.Raw != "" && !canonical {
+			e.WriteString(string(value.Raw))
+		} else {
+			s := strconv.FormatFloat(value.Number, 'g', -1, 64)
+			if canonical {
+				// strconv's 'g' format emits a leading '+' on positive
+				// exponents; canonical output omits it for a single
+				// unambiguous representation.
+				s = strings.ReplaceAll(s, "e+", "e")
+			}
+			e.WriteString(s)
+		}
 	case TypeString:
-		return stringifyString(value.String), nil
+		e.WriteString(stringifyStringCfg(value.String, e.cfg))
 	case TypeBigInt:
-		return value.BigInt.String() + "n", nil
+		if e.cfg != nil && e.cfg.bigIntAsString {
+			e.WriteString(stringifyStringCfg(value.BigInt.String(), e.cfg))
+		} else {
+			e.WriteString(value.BigInt.String())
+			e.WriteByte('n')
+		}
 	case TypeDecimal128:
-		return value.Decimal.String() + "m", nil
+		if value.Decimal.IsNaN() || value.Decimal.IsInf() {
+			e.WriteString(value.Decimal.String())
+		} else {
+			s := value.Decimal.String()
+			if e.cfg != nil && e.cfg.decimal128Precision > 0 {
+				s = roundDecimalString(s, e.cfg.decimal128Precision)
+			}
+			e.WriteString(s)
+			e.WriteByte('m')
+		}
 	case TypeUUID:
-		return value.UUID.String(), nil
+		e.WriteString(value.UUID.String())
 	case TypeDate:
-		return value.Date.String(), nil
+		if e.cfg != nil && e.cfg.dateFormat != "" {
+			e.WriteString(value.Date.Time.Format(e.cfg.dateFormat))
+		} else {
+			e.WriteString(value.Date.String())
+		}
 	case TypeArray:
-		return stringifyArray(value.Array)
+		return e.encodeArray(value.Array, depth)
 	case TypeObject:
-		return stringifyObject(value.Object)
+		return e.encodeObject(value.Object, depth)
+	case TypeExtended:
+		rt, ok := lookupTypeByName(value.Extended.Name)
+		if !ok || rt.suffix == 0 {
+			return fmt.Errorf("kjson: no registered literal suffix for extended type %q", value.Extended.Name)
+		}
+		e.WriteString(value.Extended.Raw)
+		e.WriteByte(rt.suffix)
 	default:
-		return "", fmt.Errorf("unknown value type: %v", value.Type)
+		return fmt.Errorf("unknown value type: %v", value.Type)
 	}
+	return nil
+}
+
+// roundDecimalString formats a Decimal128 literal rounded to n digits
+// after the decimal point, for callers that asked for a fixed precision
+// via WithDecimal128Precision in exchange for Decimal128's usual
+// arbitrary precision.
+func roundDecimalString(s string, n int) string {
+	d, err := NewDecimal128(s)
+	if err != nil {
+		return s
+	}
+	return d.Round(n).String()
+}
+
+// stringifyStringCfg is stringifyString, except it honors cfg's quote
+// style instead of always choosing the quote with the fewest escapes, and
+// applies cfg's HTML escaping when requested.
+func stringifyStringCfg(s string, cfg *config) string {
+	if cfg == nil {
+		return stringifyString(s)
+	}
+
+	var out string
+	if quote, forced := cfg.quoteStyle.quoteChar(); forced {
+		out = string(quote) + escapeString(s, quote) + string(quote)
+	} else {
+		out = stringifyString(s)
+	}
+
+	if cfg.escapeHTML {
+		out = escapeHTML(out)
+	}
+	return out
+}
+
+// escapeHTML replaces <, >, and & with their \u00XX escapes so kJSON
+// output embedded in an HTML document (e.g. inside a <script> tag) can't
+// be misinterpreted, matching encoding/json's HTML escaping.
+func escapeHTML(s string) string {
+	s = strings.ReplaceAll(s, "<", "\\u003c")
+	s = strings.ReplaceAll(s, ">", "\\u003e")
+	s = strings.ReplaceAll(s, "&", "\\u0026")
+	return s
 }
 
 // stringifyString escapes and quotes a string using smart quote selection.
@@ -101,7 +205,7 @@ func escapeString(s string, quote rune) string {
 	case '\'':
 		escaped = strings.ReplaceAll(escaped, "'", "\\'")
 	case '"':
-		escaped = strings.ReplaceAll(escaped, `"`, `\\"`)
+		escaped = strings.ReplaceAll(escaped, `"`, `\"`)
 	case '`':
 		escaped = strings.ReplaceAll(escaped, "`", "\\`")
 	}
@@ -109,50 +213,161 @@ func escapeString(s string, quote rune) string {
 	return escaped
 }
 
-// stringifyArray converts an array to kJSON string.
-func stringifyArray(arr []*Value) (string, error) {
+// encodeArray writes an array's kJSON text form, indenting across multiple
+// lines when cfg asks for it.
+func (e *encodeState) encodeArray(arr []*Value, depth int) error {
+	if e.cfg != nil && (e.cfg.indent != "" || e.cfg.prefix != "") {
+		return e.encodeIndentArray(arr, depth)
+	}
+
 	if len(arr) == 0 {
-		return "[]", nil
+		e.WriteString("[]")
+		return nil
 	}
-	
-	var parts []string
-	for _, item := range arr {
-		str, err := stringifyValue(item)
-		if err != nil {
-			return "", err
+
+	e.WriteByte('[')
+	for i, item := range arr {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		if err := e.encodeValue(item, depth); err != nil {
+			return err
 		}
-		parts = append(parts, str)
 	}
-	
-	return "[" + strings.Join(parts, ",") + "]", nil
+	e.WriteByte(']')
+	return nil
 }
 
-// stringifyObject converts an object to kJSON string.
-func stringifyObject(obj map[string]*Value) (string, error) {
+// encodeObject writes an object's kJSON text form, indenting across
+// multiple lines when cfg asks for it.
+func (e *encodeState) encodeObject(obj map[string]*Value, depth int) error {
+	if e.cfg != nil && (e.cfg.indent != "" || e.cfg.prefix != "") {
+		return e.encodeIndentObject(obj, depth)
+	}
+
 	if len(obj) == 0 {
-		return "{}", nil
+		e.WriteString("{}")
+		return nil
 	}
-	
-	var parts []string
-	for key, value := range obj {
-		keyStr := quoteKey(key)
-		valueStr, err := stringifyValue(value)
-		if err != nil {
-			return "", err
+
+	e.WriteByte('{')
+	for i, key := range e.objectKeys(obj) {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.WriteString(quoteKey(key, e.cfg))
+		e.WriteByte(':')
+		if err := e.encodeValue(obj[key], depth); err != nil {
+			return err
 		}
-		parts = append(parts, keyStr+":"+valueStr)
 	}
-	
-	return "{" + strings.Join(parts, ",") + "}", nil
+	e.WriteByte('}')
+	return nil
 }
 
-// quoteKey quotes a key if necessary (JSON5 style unquoted keys).
-func quoteKey(key string) string {
-	// Check if key can be unquoted (simple identifier)
-	if isValidUnquotedKey(key) {
+// objectKeys returns obj's keys, sorted lexicographically when e.cfg asks
+// for canonical output and in Go's randomized map order otherwise.
+func (e *encodeState) objectKeys(obj map[string]*Value) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	if e.cfg != nil && e.cfg.canonical {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// encodeIndentArray renders an array across multiple lines using cfg's
+// prefix/indent, following the same convention as json.Indent. It also
+// emits each element's LeadingComments and TrailingComment.
+func (e *encodeState) encodeIndentArray(arr []*Value, depth int) error {
+	if len(arr) == 0 {
+		e.WriteString("[]")
+		return nil
+	}
+
+	childPrefix := e.cfg.prefix + strings.Repeat(e.cfg.indent, depth+1)
+	closePrefix := e.cfg.prefix + strings.Repeat(e.cfg.indent, depth)
+
+	e.WriteString("[\n")
+	for i, item := range arr {
+		for _, c := range item.LeadingComments {
+			e.WriteString(childPrefix)
+			e.WriteString("// ")
+			e.WriteString(c)
+			e.WriteByte('\n')
+		}
+		e.WriteString(childPrefix)
+		if err := e.encodeValue(item, depth+1); err != nil {
+			return err
+		}
+		if i < len(arr)-1 {
+			e.WriteByte(',')
+		}
+		if item.TrailingComment != "" {
+			e.WriteString(" // ")
+			e.WriteString(item.TrailingComment)
+		}
+		e.WriteByte('\n')
+	}
+	e.WriteString(closePrefix)
+	e.WriteByte(']')
+	return nil
+}
+
+// encodeIndentObject renders an object across multiple lines using cfg's
+// prefix/indent, following the same convention as json.Indent. It also
+// emits each member's LeadingComments and TrailingComment.
+func (e *encodeState) encodeIndentObject(obj map[string]*Value, depth int) error {
+	if len(obj) == 0 {
+		e.WriteString("{}")
+		return nil
+	}
+
+	childPrefix := e.cfg.prefix + strings.Repeat(e.cfg.indent, depth+1)
+	closePrefix := e.cfg.prefix + strings.Repeat(e.cfg.indent, depth)
+
+	keys := e.objectKeys(obj)
+
+	e.WriteString("{\n")
+	for i, key := range keys {
+		value := obj[key]
+		for _, c := range value.LeadingComments {
+			e.WriteString(childPrefix)
+			e.WriteString("// ")
+			e.WriteString(c)
+			e.WriteByte('\n')
+		}
+		e.WriteString(childPrefix)
+		e.WriteString(quoteKey(key, e.cfg))
+		e.WriteString(": ")
+		if err := e.encodeValue(value, depth+1); err != nil {
+			return err
+		}
+		if i < len(keys)-1 {
+			e.WriteByte(',')
+		}
+		if value.TrailingComment != "" {
+			e.WriteString(" // ")
+			e.WriteString(value.TrailingComment)
+		}
+		e.WriteByte('\n')
+	}
+	e.WriteString(closePrefix)
+	e.WriteByte('}')
+	return nil
+}
+
+// quoteKey quotes a key if necessary (JSON5 style unquoted keys). Canonical
+// output always quotes every key, even identifier-like ones, so the result
+// is byte-stable regardless of what a key happens to look like.
+func quoteKey(key string, cfg *config) string {
+	canonical := cfg != nil && cfg.canonical
+	if !canonical && isValidUnquotedKey(key) {
 		return key
 	}
-	return stringifyString(key)
+	return stringifyStringCfg(key, cfg)
 }
 
 // isValidUnquotedKey checks if a key can be used without quotes.
@@ -215,6 +430,12 @@ func toKJsonValueReflect(rv reflect.Value) (*Value, error) {
 		rv = rv.Elem()
 	}
 	
+	// Consult the third-party type registry before kJSON's own special
+	// types, so a caller can override one of them if they need to.
+	if rt, ok := lookupTypeByGoType(rv.Type()); ok {
+		return rt.codec.Marshal(rv)
+	}
+
 	// Check for special types first before generic kinds
 	if rv.Type() == reflect.TypeOf(time.Time{}) {
 		t := rv.Interface().(time.Time)
@@ -252,6 +473,10 @@ func toKJsonValueReflect(rv reflect.Value) (*Value, error) {
 		return &Value{Type: TypeDecimal128, Decimal: d}, nil
 	}
 	
+	if rv.Type() == reflect.TypeOf(Number("")) {
+		return &Value{Type: TypeNumber, Raw: rv.Interface().(Number)}, nil
+	}
+
 	if rv.Type() == reflect.TypeOf(Date{}) {
 		d := rv.Interface().(Date)
 		return &Value{Type: TypeDate, Date: &d}, nil
@@ -265,6 +490,12 @@ func toKJsonValueReflect(rv reflect.Value) (*Value, error) {
 		return &Value{Type: TypeDate, Date: d}, nil
 	}
 
+	// Let a user-defined KJsonMarshaler, json.Marshaler, or
+	// encoding.TextMarshaler take over before falling back to reflection.
+	if value, ok, err := marshalViaHook(rv); ok {
+		return value, err
+	}
+
 	switch rv.Kind() {
 	case reflect.Bool:
 		return &Value{Type: TypeBool, Bool: rv.Bool()}, nil
@@ -335,40 +566,106 @@ func toKJsonObject(rv reflect.Value) (*Value, error) {
 	return &Value{Type: TypeObject, Object: obj}, nil
 }
 
-// toKJsonStruct converts a struct to kJSON object.
+// toKJsonStruct converts a struct to kJSON object, flattening any field
+// tagged (or anonymous-promoted) ",inline" into the same object instead
+// of nesting it.
 func toKJsonStruct(rv reflect.Value) (*Value, error) {
-	rt := rv.Type()
 	obj := make(map[string]*Value)
-	
+	if err := addKJsonStructFields(rv, obj); err != nil {
+		return nil, err
+	}
+	return &Value{Type: TypeObject, Object: obj}, nil
+}
+
+// addKJsonStructFields encodes rv's fields into obj, recursing into
+// ",inline" fields so their fields land at the same level as obj's own.
+func addKJsonStructFields(rv reflect.Value, obj map[string]*Value) error {
+	rt := rv.Type()
+
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
 		fieldValue := rv.Field(i)
-		
+
 		// Skip unexported fields
 		if !fieldValue.CanInterface() {
 			continue
 		}
-		
-		// Get field name from tags
-		fieldName, shouldInclude := getStructTag(field)
+
+		tag, shouldInclude := parseStructTag(field)
 		if !shouldInclude {
 			continue
 		}
-		
-		// Check omitempty
-		if isOmitEmpty(field) && isEmptyValue(fieldValue) {
+
+		if tag.inline {
+			if err := addInlineKJsonFields(fieldValue, obj); err != nil {
+				return err
+			}
 			continue
 		}
-		
+
+		if tag.omitempty && isEmptyValue(fieldValue) {
+			continue
+		}
+
 		kJsonValue, err := toKJsonValueReflect(fieldValue)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		
-		obj[fieldName] = kJsonValue
+		if tag.asString {
+			kJsonValue = asStringValue(kJsonValue)
+		}
+
+		obj[tag.name] = kJsonValue
+	}
+
+	return nil
+}
+
+// addInlineKJsonFields flattens an ",inline" field - an embedded struct,
+// a pointer to one, or a map[string]V of additional properties - into
+// obj.
+func addInlineKJsonFields(fieldValue reflect.Value, obj map[string]*Value) error {
+	for fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.Struct:
+		return addKJsonStructFields(fieldValue, obj)
+	case reflect.Map:
+		if fieldValue.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("inline map keys must be strings")
+		}
+		for _, key := range fieldValue.MapKeys() {
+			kJsonValue, err := toKJsonValueReflect(fieldValue.MapIndex(key))
+			if err != nil {
+				return err
+			}
+			obj[key.String()] = kJsonValue
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot inline %v, want struct or map", fieldValue.Type())
+	}
+}
+
+// asStringValue rewrites a TypeBool or TypeNumber value to its quoted
+// string form, for fields tagged ",string" the way encoding/json does.
+func asStringValue(v *Value) *Value {
+	switch v.Type {
+	case TypeBool:
+		if v.Bool {
+			return &Value{Type: TypeString, String: "true"}
+		}
+		return &Value{Type: TypeString, String: "false"}
+	case TypeNumber:
+		return &Value{Type: TypeString, String: formatNumberValue(&Value{Type: TypeNumber, Number: v.Number, Raw: v.Raw})}
+	default:
+		return v
 	}
-	
-	return &Value{Type: TypeObject, Object: obj}, nil
 }
 
 // isEmptyValue checks if a reflect.Value represents an empty value.
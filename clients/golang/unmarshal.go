@@ -2,29 +2,199 @@ package kjson
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// minInt64Float, maxInt64Float, and maxUint64Float are int64/uint64's
+// bounds as the nearest float64, all exactly representable (powers of
+// two), used to bounds-check a plain number before converting it, since
+// int64(n)/uint64(n) for an out-of-range n is undefined rather than a
+// reliably detectable overflow.
+const (
+	minInt64Float  = -9223372036854775808.0 // -2^63
+	maxInt64Float  = 9223372036854775808.0  // 2^63
+	maxUint64Float = 18446744073709551616.0 // 2^64
+)
+
+// unmarshalOptions carries optional strictness knobs through the recursive
+// fromKJsonValueReflect walk without changing Unmarshal's default,
+// permissive behavior.
+type unmarshalOptions struct {
+	disallowUnknownFields   bool
+	disallowOverflow        bool
+	disallowFractionalToInt bool
+	disallowPrecisionLoss   bool
+}
+
+// UnmarshalOverflowError reports that a kJSON numeric value could not be
+// represented in a Go destination type without overflowing it, truncating
+// a fractional part, or losing precision, as one of DecoderOptions'
+// strict knobs requires.
+type UnmarshalOverflowError struct {
+	Value string
+	Type  reflect.Type
+}
+
+func (e *UnmarshalOverflowError) Error() string {
+	return fmt.Sprintf("kjson: value %s overflows %v", e.Value, e.Type)
+}
+
+// UnmarshalError reports that Unmarshal failed to decode the value at a
+// specific location in the document. Path is a JSON-Pointer-style location
+// (e.g. "/users/3/address/zip"), empty when the failure happened at the
+// document root.
+type UnmarshalError struct {
+	Path  string
+	Value string
+	Type  reflect.Type
+	Cause error
+}
+
+func (e *UnmarshalError) Error() string {
+	if e.Path == "" {
+		return e.Cause.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Cause)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Cause
+}
+
+// pathElem is one segment of an UnmarshalState's path, either an object
+// key or an array index.
+type pathElem struct {
+	key   string
+	index int
+	isKey bool
+}
+
+func (p pathElem) String() string {
+	if p.isKey {
+		return p.key
+	}
+	return strconv.Itoa(p.index)
+}
+
+// unmarshalState threads unmarshalOptions and the current JSON-Pointer-
+// style path through the recursive fromKJsonValueReflect walk, as a small
+// stack pushed and popped by setArray/setObject/setStruct, so an error deep
+// inside a document can be reported with its full location instead of just
+// the innermost failure.
+type unmarshalState struct {
+	opts unmarshalOptions
+	path []pathElem
+}
+
+func (st *unmarshalState) pushKey(key string) {
+	st.path = append(st.path, pathElem{key: key, isKey: true})
+}
+
+func (st *unmarshalState) pushIndex(i int) {
+	st.path = append(st.path, pathElem{index: i})
+}
+
+func (st *unmarshalState) pop() {
+	st.path = st.path[:len(st.path)-1]
+}
+
+// pointer renders st's current path as a JSON Pointer (RFC 6901) location.
+func (st *unmarshalState) pointer() string {
+	if len(st.path) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, e := range st.path {
+		b.WriteByte('/')
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+// wrapError attaches st's current path, value's literal text, and rv's
+// type to a non-nil err, unless err is already an *UnmarshalError - one of
+// st's own recursive calls having wrapped it closer to the failure, in
+// which case it's returned unchanged so a document's path isn't rebuilt at
+// every enclosing level.
+func (st *unmarshalState) wrapError(value *Value, rv reflect.Value, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*UnmarshalError); ok {
+		return err
+	}
+	return &UnmarshalError{
+		Path:  st.pointer(),
+		Value: formatValueForError(value),
+		Type:  rv.Type(),
+		Cause: err,
+	}
+}
+
+// formatValueForError renders value's literal text for an UnmarshalError's
+// Value field, preferring the original source text where kJSON preserves
+// one.
+func formatValueForError(value *Value) string {
+	switch value.Type {
+	case TypeNull:
+		return "null"
+	case TypeBool:
+		if value.Bool {
+			return "true"
+		}
+		return "false"
+	case TypeNumber:
+		return formatNumberValue(value)
+	case TypeString:
+		return value.String
+	case TypeBigInt:
+		return value.BigInt.String()
+	case TypeDecimal128:
+		return value.Decimal.String()
+	case TypeUUID:
+		return value.UUID.String()
+	case TypeDate:
+		return value.Date.String()
+	case TypeArray:
+		return "[array]"
+	case TypeObject:
+		return "[object]"
+	case TypeExtended:
+		return value.Extended.Raw
+	default:
+		return ""
+	}
+}
+
 // fromKJsonValue converts a kJSON Value to a Go value.
 func fromKJsonValue(value *Value, v interface{}) error {
+	return fromKJsonValueOpts(value, v, unmarshalOptions{})
+}
+
+// fromKJsonValueOpts is fromKJsonValue with strictness knobs attached, for
+// callers like Decoder that expose them.
+func fromKJsonValueOpts(value *Value, v interface{}, opts unmarshalOptions) error {
 	rv := reflect.ValueOf(v)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
 		return fmt.Errorf("kjson: Unmarshal target must be a non-nil pointer")
 	}
-	
-	return fromKJsonValueReflect(value, rv.Elem())
+
+	st := &unmarshalState{opts: opts}
+	return fromKJsonValueReflect(value, rv.Elem(), st)
 }
 
 // fromKJsonValueReflect converts a kJSON Value to a reflect.Value.
-func fromKJsonValueReflect(value *Value, rv reflect.Value) error {
+func fromKJsonValueReflect(value *Value, rv reflect.Value, st *unmarshalState) error {
 	// Handle null values
 	if value.Type == TypeNull {
-		if rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface || 
-		   rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice {
+		if rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface ||
+			rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice {
 			rv.Set(reflect.Zero(rv.Type()))
 			return nil
 		}
@@ -32,57 +202,95 @@ func fromKJsonValueReflect(value *Value, rv reflect.Value) error {
 		rv.Set(reflect.Zero(rv.Type()))
 		return nil
 	}
-	
+
 	// Handle pointers
 	if rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
-		return fromKJsonValueReflect(value, rv.Elem())
+		if !isBuiltinDestType(rv.Type()) {
+			if ok, err := unmarshalViaHook(value, rv); ok {
+				return st.wrapError(value, rv, err)
+			}
+		}
+		return fromKJsonValueReflect(value, rv.Elem(), st)
 	}
-	
+
 	// Handle interfaces
 	if rv.Kind() == reflect.Interface {
 		// Set the interface to the appropriate Go type
 		goValue, err := valueToGoInterface(value)
 		if err != nil {
-			return err
+			return st.wrapError(value, rv, err)
 		}
 		rv.Set(reflect.ValueOf(goValue))
 		return nil
 	}
-	
+
+	// Consult the third-party type registry before falling back to
+	// hooks and reflection, so a registered TypeCodec always handles its
+	// Go type, even one kJSON already has native handling for.
+	if rt, ok := lookupTypeByGoType(rv.Type()); ok {
+		return st.wrapError(value, rv, rt.codec.Unmarshal(value, rv))
+	}
+
+	// Let a user-defined KJsonUnmarshaler, json.Unmarshaler, or
+	// encoding.TextUnmarshaler take over before falling back to
+	// reflection, unless rv is one of the types kJSON already has native
+	// handling for.
+	if !isBuiltinDestType(rv.Type()) {
+		if ok, err := unmarshalViaHook(value, rv); ok {
+			return st.wrapError(value, rv, err)
+		}
+	}
+
+	var err error
 	switch value.Type {
 	case TypeBool:
-		return setBool(rv, value.Bool)
-		
+		err = setBool(rv, value.Bool)
+
 	case TypeNumber:
-		return setNumber(rv, value.Number)
-		
+		err = setNumberValue(rv, value, st.opts)
+
 	case TypeString:
-		return setString(rv, value.String)
-		
+		err = setString(rv, value.String)
+
 	case TypeBigInt:
-		return setBigInt(rv, value.BigInt)
-		
+		err = setBigInt(rv, value.BigInt, st.opts)
+
 	case TypeDecimal128:
-		return setDecimal128(rv, value.Decimal)
-		
+		err = setDecimal128(rv, value.Decimal, st.opts)
+
 	case TypeUUID:
-		return setUUID(rv, value.UUID)
-		
+		err = setUUID(rv, value.UUID)
+
 	case TypeDate:
-		return setDate(rv, value.Date)
-		
+		err = setDate(rv, value.Date)
+
 	case TypeArray:
-		return setArray(rv, value.Array)
-		
+		err = setArray(rv, value.Array, st)
+
 	case TypeObject:
-		return setObject(rv, value.Object)
-		
+		err = setObject(rv, value.Object, st)
+
+	case TypeExtended:
+		err = setExtendedValue(rv, value.Extended, st)
+
 	default:
-		return fmt.Errorf("unknown kJSON type: %v", value.Type)
+		err = fmt.Errorf("unknown kJSON type: %v", value.Type)
 	}
+	return st.wrapError(value, rv, err)
+}
+
+// setExtendedValue decodes an ExtendedValue through its registered
+// TypeCodec, found by the name RegisterTypeSuffix's parser recognizer
+// attached to the literal.
+func setExtendedValue(rv reflect.Value, ext *ExtendedValue, st *unmarshalState) error {
+	rt, ok := lookupTypeByName(ext.Name)
+	if !ok {
+		return fmt.Errorf("kjson: no type registered for extended literal %q (%s)", ext.Name, ext.Raw)
+	}
+	return rt.codec.Unmarshal(&Value{Type: TypeExtended, Extended: ext}, rv)
 }
 
 // valueToGoInterface converts a kJSON Value to a Go interface{}.
@@ -93,6 +301,9 @@ func valueToGoInterface(value *Value) (interface{}, error) {
 	case TypeBool:
 		return value.Bool, nil
 	case TypeNumber:
+		if value.Raw != "" {
+			return value.Raw, nil
+		}
 		return value.Number, nil
 	case TypeString:
 		return value.String, nil
@@ -124,6 +335,16 @@ func valueToGoInterface(value *Value) (interface{}, error) {
 			obj[key] = goVal
 		}
 		return obj, nil
+	case TypeExtended:
+		rt, ok := lookupTypeByName(value.Extended.Name)
+		if !ok {
+			return nil, fmt.Errorf("kjson: no type registered for extended literal %q (%s)", value.Extended.Name, value.Extended.Raw)
+		}
+		dest := reflect.New(rt.goType).Elem()
+		if err := rt.codec.Unmarshal(value, dest); err != nil {
+			return nil, err
+		}
+		return dest.Interface(), nil
 	default:
 		return nil, fmt.Errorf("unknown kJSON type: %v", value.Type)
 	}
@@ -140,16 +361,54 @@ func setBool(rv reflect.Value, b bool) error {
 	}
 }
 
-// setNumber sets a numeric value.
-func setNumber(rv reflect.Value, n float64) error {
+// setNumber sets a numeric value, honoring opts.disallowFractionalToInt
+// (reject a non-integral value for an int/uint destination),
+// opts.disallowOverflow (reject a value reflect.Value.OverflowInt/
+// OverflowUint/OverflowFloat says doesn't fit), and opts.disallowPrecisionLoss
+// (reject a float32 destination that can't represent the literal exactly),
+// returning a typed *UnmarshalOverflowError when a strict check fails.
+func setNumber(rv reflect.Value, value *Value, opts unmarshalOptions) error {
+	n := value.Number
 	switch rv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		rv.SetInt(int64(n))
+		if opts.disallowFractionalToInt && n != math.Trunc(n) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		// n may be out of int64's range entirely, in which case int64(n)
+		// below is not just narrower-type overflow but undefined - check
+		// against int64's own bounds before converting, not after.
+		if opts.disallowOverflow && (n < minInt64Float || n >= maxInt64Float) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		i := int64(n)
+		if opts.disallowOverflow && rv.OverflowInt(i) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		rv.SetInt(i)
 		return nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		rv.SetUint(uint64(n))
+		if opts.disallowFractionalToInt && n != math.Trunc(n) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		// Same as the int case above: bounds-check against uint64 itself
+		// before converting, since uint64(n) is undefined for n outside
+		// [0, 2^64).
+		if opts.disallowOverflow && (n < 0 || n >= maxUint64Float) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		u := uint64(n)
+		if opts.disallowOverflow && rv.OverflowUint(u) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		rv.SetUint(u)
 		return nil
 	case reflect.Float32, reflect.Float64:
+		if opts.disallowOverflow && rv.OverflowFloat(n) {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
+		if opts.disallowPrecisionLoss && rv.Kind() == reflect.Float32 && float64(float32(n)) != n {
+			return &UnmarshalOverflowError{Value: formatNumberValue(value), Type: rv.Type()}
+		}
 		rv.SetFloat(n)
 		return nil
 	default:
@@ -157,6 +416,31 @@ func setNumber(rv reflect.Value, n float64) error {
 	}
 }
 
+// formatNumberValue renders a TypeNumber Value for an error message,
+// preferring the original literal text over a reformatted float64.
+func formatNumberValue(value *Value) string {
+	if value.Raw != "" {
+		return string(value.Raw)
+	}
+	return strconv.FormatFloat(value.Number, 'g', -1, 64)
+}
+
+// setNumberValue sets a TypeNumber value. When the destination is a Number,
+// it preserves the original literal text (falling back to a formatted
+// float64 if the value wasn't decoded with UseNumber); otherwise it sets
+// the parsed float64 as usual.
+func setNumberValue(rv reflect.Value, value *Value, opts unmarshalOptions) error {
+	if rv.Type() == reflect.TypeOf(Number("")) {
+		if value.Raw != "" {
+			rv.SetString(string(value.Raw))
+		} else {
+			rv.SetString(strconv.FormatFloat(value.Number, 'g', -1, 64))
+		}
+		return nil
+	}
+	return setNumber(rv, value, opts)
+}
+
 // setString sets a string value.
 func setString(rv reflect.Value, s string) error {
 	switch rv.Kind() {
@@ -168,63 +452,97 @@ func setString(rv reflect.Value, s string) error {
 	}
 }
 
-// setBigInt sets a BigInt value.
-func setBigInt(rv reflect.Value, b *BigInt) error {
+// setBigInt sets a BigInt value, gating narrower-than-int64 overflow
+// checks behind opts.disallowOverflow. A BigInt that doesn't fit in int64
+// at all always fails, since there is no sane lossy fallback to truncate
+// to.
+func setBigInt(rv reflect.Value, b *BigInt, opts unmarshalOptions) error {
 	if rv.Type() == reflect.TypeOf(BigInt{}) {
 		rv.Set(reflect.ValueOf(*b))
 		return nil
 	}
-	
+
 	if rv.Type() == reflect.TypeOf(&BigInt{}) {
 		rv.Set(reflect.ValueOf(b))
 		return nil
 	}
-	
+
+	if rv.Type() == reflect.TypeOf(Number("")) {
+		rv.SetString(b.String())
+		return nil
+	}
+
 	// Try to convert to numeric types if possible
 	if rv.Kind() >= reflect.Int && rv.Kind() <= reflect.Uint64 {
 		// Parse the BigInt as int64
 		val, err := strconv.ParseInt(b.String(), 10, 64)
 		if err != nil {
-			return fmt.Errorf("BigInt too large for %v: %s", rv.Type(), b.String())
+			return &UnmarshalOverflowError{Value: b.String(), Type: rv.Type()}
 		}
-		
+
 		switch rv.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if opts.disallowOverflow && rv.OverflowInt(val) {
+				return &UnmarshalOverflowError{Value: b.String(), Type: rv.Type()}
+			}
 			rv.SetInt(val)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			if val < 0 {
 				return fmt.Errorf("negative BigInt cannot be assigned to unsigned type %v", rv.Type())
 			}
+			if opts.disallowOverflow && rv.OverflowUint(uint64(val)) {
+				return &UnmarshalOverflowError{Value: b.String(), Type: rv.Type()}
+			}
 			rv.SetUint(uint64(val))
 		}
 		return nil
 	}
-	
+
 	return fmt.Errorf("cannot unmarshal BigInt into %v", rv.Type())
 }
 
-// setDecimal128 sets a Decimal128 value.
-func setDecimal128(rv reflect.Value, d *Decimal128) error {
+// setDecimal128 sets a Decimal128 value. opts.disallowPrecisionLoss
+// rejects a conversion to float32/float64 that can't round-trip back to
+// an equal Decimal128, and opts.disallowOverflow rejects a magnitude the
+// destination float type can't represent at all.
+func setDecimal128(rv reflect.Value, d *Decimal128, opts unmarshalOptions) error {
 	if rv.Type() == reflect.TypeOf(Decimal128{}) {
 		rv.Set(reflect.ValueOf(*d))
 		return nil
 	}
-	
+
 	if rv.Type() == reflect.TypeOf(&Decimal128{}) {
 		rv.Set(reflect.ValueOf(d))
 		return nil
 	}
-	
+
+	if rv.Type() == reflect.TypeOf(Number("")) {
+		rv.SetString(d.String())
+		return nil
+	}
+
 	// Try to convert to numeric types
 	if rv.Kind() >= reflect.Float32 && rv.Kind() <= reflect.Float64 {
 		val, err := strconv.ParseFloat(d.String(), 64)
 		if err != nil {
 			return fmt.Errorf("cannot convert Decimal128 to float: %s", d.String())
 		}
+		if opts.disallowOverflow && rv.OverflowFloat(val) {
+			return &UnmarshalOverflowError{Value: d.String(), Type: rv.Type()}
+		}
+		if opts.disallowPrecisionLoss {
+			check := val
+			if rv.Kind() == reflect.Float32 {
+				check = float64(float32(val))
+			}
+			if NewDecimal128FromFloat(check).Cmp(d) != 0 {
+				return &UnmarshalOverflowError{Value: d.String(), Type: rv.Type()}
+			}
+		}
 		rv.SetFloat(val)
 		return nil
 	}
-	
+
 	return fmt.Errorf("cannot unmarshal Decimal128 into %v", rv.Type())
 }
 
@@ -269,94 +587,254 @@ func setDate(rv reflect.Value, d *Date) error {
 }
 
 // setArray sets an array value.
-func setArray(rv reflect.Value, arr []*Value) error {
+func setArray(rv reflect.Value, arr []*Value, st *unmarshalState) error {
 	switch rv.Kind() {
 	case reflect.Slice:
 		// Create new slice
 		slice := reflect.MakeSlice(rv.Type(), len(arr), len(arr))
 		for i, item := range arr {
-			if err := fromKJsonValueReflect(item, slice.Index(i)); err != nil {
+			st.pushIndex(i)
+			err := fromKJsonValueReflect(item, slice.Index(i), st)
+			st.pop()
+			if err != nil {
 				return err
 			}
 		}
 		rv.Set(slice)
 		return nil
-		
+
 	case reflect.Array:
 		// Check length
 		if len(arr) != rv.Len() {
 			return fmt.Errorf("array length mismatch: got %d, expected %d", len(arr), rv.Len())
 		}
 		for i, item := range arr {
-			if err := fromKJsonValueReflect(item, rv.Index(i)); err != nil {
+			st.pushIndex(i)
+			err := fromKJsonValueReflect(item, rv.Index(i), st)
+			st.pop()
+			if err != nil {
 				return err
 			}
 		}
 		return nil
-		
+
 	default:
 		return fmt.Errorf("cannot unmarshal array into %v", rv.Type())
 	}
 }
 
 // setObject sets an object value.
-func setObject(rv reflect.Value, obj map[string]*Value) error {
+func setObject(rv reflect.Value, obj map[string]*Value, st *unmarshalState) error {
 	switch rv.Kind() {
 	case reflect.Map:
 		// Create new map
 		if rv.Type().Key().Kind() != reflect.String {
 			return fmt.Errorf("map key must be string, got %v", rv.Type().Key())
 		}
-		
+
 		mapValue := reflect.MakeMap(rv.Type())
 		for key, value := range obj {
 			valueReflect := reflect.New(rv.Type().Elem()).Elem()
-			if err := fromKJsonValueReflect(value, valueReflect); err != nil {
+			st.pushKey(key)
+			err := fromKJsonValueReflect(value, valueReflect, st)
+			st.pop()
+			if err != nil {
 				return err
 			}
 			mapValue.SetMapIndex(reflect.ValueOf(key), valueReflect)
 		}
 		rv.Set(mapValue)
 		return nil
-		
+
 	case reflect.Struct:
-		return setStruct(rv, obj)
-		
+		return setStruct(rv, obj, st)
+
 	default:
 		return fmt.Errorf("cannot unmarshal object into %v", rv.Type())
 	}
 }
 
-// setStruct sets a struct value from an object.
-func setStruct(rv reflect.Value, obj map[string]*Value) error {
-	rt := rv.Type()
-	
-	// Create a map of field names to field indices
-	fieldMap := make(map[string]int)
+// structFieldPath locates a destination field through zero or more
+// ",inline" structs, recording the reflect.Value.FieldByIndex-style path
+// and whether the field is tagged ",string".
+type structFieldPath struct {
+	index    []int
+	asString bool
+}
+
+// collectStructFieldPaths walks rt's fields, recursing into ",inline"
+// struct (or pointer-to-struct) fields so their fields are addressable
+// by name alongside rt's own, the way encoding/json promotes embedded
+// fields. It returns the FieldByIndex path of an ",inline" map field, if
+// any, to catch keys that don't match a struct field.
+func collectStructFieldPaths(rt reflect.Type, prefix []int, fields map[string]structFieldPath) []int {
+	var inlineMapIndex []int
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
-		
-		// Skip unexported fields
-		if !rv.Field(i).CanSet() {
+		if field.PkgPath != "" && !field.Anonymous {
+			continue // unexported
+		}
+
+		tag, shouldInclude := parseStructTag(field)
+		if !shouldInclude {
 			continue
 		}
-		
-		fieldName, shouldInclude := getStructTag(field)
-		if shouldInclude {
-			fieldMap[fieldName] = i
+
+		path := append(append([]int{}, prefix...), i)
+
+		if tag.inline {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			switch ft.Kind() {
+			case reflect.Struct:
+				if m := collectStructFieldPaths(ft, path, fields); m != nil {
+					inlineMapIndex = m
+				}
+				continue
+			case reflect.Map:
+				inlineMapIndex = path
+				continue
+			}
+		}
+
+		fields[tag.name] = structFieldPath{index: path, asString: tag.asString}
+	}
+	return inlineMapIndex
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex that allocates nil
+// pointers along the way instead of panicking, so a path through an
+// ",inline" *Struct field can be followed on a still-zero value.
+func fieldByIndexAlloc(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
 		}
+		rv = rv.Field(x)
 	}
-	
-	// Set field values
+	return rv
+}
+
+// setStruct sets a struct value from an object, decoding ",string"
+// fields from their quoted literal and routing keys that match no
+// struct field - including ones promoted from ",inline" structs - to an
+// ",inline" map field when the struct has one.
+func setStruct(rv reflect.Value, obj map[string]*Value, st *unmarshalState) error {
+	rt := rv.Type()
+
+	fields := make(map[string]structFieldPath)
+	inlineMapIndex := collectStructFieldPaths(rt, nil, fields)
+
 	for key, value := range obj {
-		if fieldIndex, exists := fieldMap[key]; exists {
-			fieldValue := rv.Field(fieldIndex)
-			if err := fromKJsonValueReflect(value, fieldValue); err != nil {
-				return fmt.Errorf("field %s: %v", key, err)
+		path, exists := fields[key]
+		if !exists {
+			if inlineMapIndex != nil {
+				if err := setInlineMapEntry(fieldByIndexAlloc(rv, inlineMapIndex), key, value, st); err != nil {
+					return err
+				}
+				continue
+			}
+			if st.opts.disallowUnknownFields {
+				return fmt.Errorf("kjson: unknown field %q in %v", key, rt)
 			}
+			continue
+		}
+
+		fieldValue := fieldByIndexAlloc(rv, path.index)
+		st.pushKey(key)
+		var err error
+		if path.asString {
+			err = setStringTaggedField(value, fieldValue, st)
+		} else {
+			err = fromKJsonValueReflect(value, fieldValue, st)
+		}
+		err = st.wrapError(value, fieldValue, err)
+		st.pop()
+		if err != nil {
+			return err
 		}
-		// Ignore unknown fields (like encoding/json)
 	}
-	
+
+	return nil
+}
+
+// setInlineMapEntry decodes value into m's element type and stores it
+// under key, initializing m if it's nil.
+func setInlineMapEntry(m reflect.Value, key string, value *Value, st *unmarshalState) error {
+	if m.Kind() != reflect.Map {
+		return fmt.Errorf("cannot inline %v, want map", m.Type())
+	}
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	elem := reflect.New(m.Type().Elem()).Elem()
+	st.pushKey(key)
+	err := fromKJsonValueReflect(value, elem, st)
+	st.pop()
+	if err != nil {
+		return err
+	}
+	m.SetMapIndex(reflect.ValueOf(key), elem)
 	return nil
+}
+
+// setStringTaggedField decodes a ",string"-tagged field: the wire value
+// is a quoted string wrapping a number or bool literal, which is parsed
+// back into rv's actual (non-string) type, the same convention
+// encoding/json uses.
+func setStringTaggedField(value *Value, rv reflect.Value, st *unmarshalState) error {
+	if value.Type == TypeNull {
+		return fromKJsonValueReflect(value, rv, st)
+	}
+	if value.Type != TypeString {
+		return fmt.Errorf("cannot unmarshal %v into %v with the \",string\" option", value.Type, rv.Type())
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value.String)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value.String, 10, 64)
+		if err != nil {
+			return err
+		}
+		if st.opts.disallowOverflow && rv.OverflowInt(n) {
+			return &UnmarshalOverflowError{Value: value.String, Type: rv.Type()}
+		}
+		rv.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value.String, 10, 64)
+		if err != nil {
+			return err
+		}
+		if st.opts.disallowOverflow && rv.OverflowUint(n) {
+			return &UnmarshalOverflowError{Value: value.String, Type: rv.Type()}
+		}
+		rv.SetUint(n)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value.String, 64)
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+		return nil
+
+	default:
+		return fmt.Errorf("cannot unmarshal string into %v with the \",string\" option", rv.Type())
+	}
 }
\ No newline at end of file
@@ -3,53 +3,286 @@ package kjson
 import (
 	"crypto/rand"
 	"fmt"
+	"net"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// gregorianOffset100ns is the number of 100-nanosecond intervals between
+// the Gregorian calendar's epoch (1582-10-15T00:00:00Z) and the Unix
+// epoch, the offset RFC 9562 UUIDv1/v6 timestamps are measured from.
+const gregorianOffset100ns = 0x01B21DD213814000
+
+// maxV7Counter is the largest value UUIDGenerator's 42-bit UUIDv7
+// sub-millisecond counter can hold before it must roll over to the next
+// millisecond.
+const maxV7Counter = (uint64(1) << 42) - 1
+
+// UUIDGenerator generates RFC 9562 UUIDs and holds the process-local
+// state (the UUIDv7 monotonic counter, and the UUIDv1/v6 node ID and
+// clock sequence) needed to keep successive UUIDs well-formed. Its zero
+// value reads the time from time.Now and randomness from crypto/rand,
+// matching the package-level UUIDv1/v4/v6/v7/v8 helpers, which share one
+// default UUIDGenerator. Tests can construct their own UUIDGenerator with
+// Now and Rand set to deterministic sources.
+type UUIDGenerator struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// Rand fills p with random bytes, following io.Reader's Read
+	// contract. Defaults to crypto/rand.Read.
+	Rand func(p []byte) (int, error)
+
+	mu sync.Mutex
+
+	lastMillis int64  // last millisecond UUIDv7 was called with
+	counter    uint64 // 42-bit monotonic sub-millisecond counter for UUIDv7
+
+	v1Init   bool
+	node     [6]byte
+	clockSeq uint16 // 14-bit clock sequence for UUIDv1/UUIDv6
+}
+
+// defaultUUIDGenerator backs the package-level UUID helpers, so repeated
+// calls to UUIDv7 within the same process stay monotonic.
+var defaultUUIDGenerator = &UUIDGenerator{}
+
+func (g *UUIDGenerator) now() time.Time {
+	if g.Now != nil {
+		return g.Now()
+	}
+	return time.Now()
+}
+
+// randRead fills p with random bytes, panicking if the configured source
+// fails - the same failure mode crypto/rand.Read has on this package's
+// supported platforms.
+func (g *UUIDGenerator) randRead(p []byte) {
+	read := g.Rand
+	if read == nil {
+		read = rand.Read
+	}
+	if _, err := read(p); err != nil {
+		panic(fmt.Sprintf("failed to generate random bytes for UUID: %v", err))
+	}
+}
+
 // UUIDv4 generates a UUIDv4 (random) that matches the TypeScript implementation.
-func UUIDv4() uuid.UUID {
-	bytes := make([]byte, 16)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		panic(fmt.Sprintf("failed to generate random bytes for UUIDv4: %v", err))
+func UUIDv4() uuid.UUID { return defaultUUIDGenerator.UUIDv4() }
+
+// UUIDv4 generates a UUIDv4 (random) UUID.
+func (g *UUIDGenerator) UUIDv4() uuid.UUID {
+	b := make([]byte, 16)
+	g.randRead(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40 // Version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+
+	return bytesToUUID(b)
+}
+
+// UUIDv7 generates a UUIDv7 (timestamp-based) UUID using the default
+// generator, whose monotonic counter keeps successive calls within the
+// same millisecond in order.
+func UUIDv7() uuid.UUID { return defaultUUIDGenerator.UUIDv7() }
+
+// UUIDv7 generates a UUIDv7 UUID. Calls within the same millisecond
+// increment a 42-bit counter seeded with fresh randomness each new
+// millisecond (RFC 9562 §6.2 Method 1, Fixed-Length Dedicated Counter
+// Bits), with 12 counter bits in rand_a and the remaining 30 in the top
+// of rand_b, so successive IDs stay monotonically ordered even when
+// generated faster than the clock ticks. If the counter saturates within
+// a millisecond, the timestamp is advanced by one millisecond instead of
+// wrapping.
+func (g *UUIDGenerator) UUIDv7() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now().UnixMilli()
+	switch {
+	case now > g.lastMillis:
+		g.lastMillis = now
+		g.counter = g.randCounterLocked()
+	default:
+		now = g.lastMillis
+		g.counter++
+		if g.counter > maxV7Counter {
+			g.lastMillis++
+			now = g.lastMillis
+			g.counter = g.randCounterLocked()
+		}
 	}
-	
-	// Set version (4) and variant bits
-	bytes[6] = (bytes[6] & 0x0f) | 0x40 // Version 4
-	bytes[8] = (bytes[8] & 0x3f) | 0x80 // Variant 10
-	
-	return bytesToUUID(bytes)
+
+	counterA := (g.counter >> 30) & 0xfff // top 12 bits, into rand_a
+	counterB := g.counter & 0x3fffffff    // bottom 30 bits, into rand_b
+
+	b := make([]byte, 16)
+	b[0] = byte(now >> 40)
+	b[1] = byte(now >> 32)
+	b[2] = byte(now >> 24)
+	b[3] = byte(now >> 16)
+	b[4] = byte(now >> 8)
+	b[5] = byte(now)
+
+	b[6] = byte(counterA >> 8)
+	b[7] = byte(counterA)
+
+	b[8] = byte(counterB >> 24)
+	b[9] = byte(counterB >> 16)
+	b[10] = byte(counterB >> 8)
+	b[11] = byte(counterB)
+
+	random := make([]byte, 4)
+	g.randRead(random)
+	copy(b[12:], random)
+
+	b[6] = (b[6] & 0x0f) | 0x70 // Version 7
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+
+	return bytesToUUID(b)
 }
 
-// UUIDv7 generates a UUIDv7 (timestamp-based) that matches the TypeScript implementation.
-func UUIDv7() uuid.UUID {
-	bytes := make([]byte, 16)
-	now := time.Now().UnixMilli()
-	
-	// Timestamp (48 bits) - split into 6 bytes
-	bytes[0] = byte((now >> 40) & 0xff)
-	bytes[1] = byte((now >> 32) & 0xff)
-	bytes[2] = byte((now >> 24) & 0xff)
-	bytes[3] = byte((now >> 16) & 0xff)
-	bytes[4] = byte((now >> 8) & 0xff)
-	bytes[5] = byte(now & 0xff)
-	
-	// Random data for the rest (10 bytes)
-	random := make([]byte, 10)
-	_, err := rand.Read(random)
-	if err != nil {
-		panic(fmt.Sprintf("failed to generate random bytes for UUIDv7: %v", err))
+// randCounterLocked draws a fresh 42-bit value to reseed UUIDv7's
+// sub-millisecond counter at the start of a new millisecond. g.mu must
+// already be held.
+func (g *UUIDGenerator) randCounterLocked() uint64 {
+	var buf [6]byte
+	g.randRead(buf[:])
+	v := uint64(buf[0])<<40 | uint64(buf[1])<<32 | uint64(buf[2])<<24 |
+		uint64(buf[3])<<16 | uint64(buf[4])<<8 | uint64(buf[5])
+	return v & maxV7Counter
+}
+
+// UUIDv1 generates a UUIDv1 (Gregorian timestamp + node ID) UUID using
+// the default generator.
+func UUIDv1() uuid.UUID { return defaultUUIDGenerator.UUIDv1() }
+
+// UUIDv1 generates a UUIDv1 UUID from a 60-bit Gregorian timestamp (100ns
+// intervals since 1582-10-15), a clock sequence randomized once per
+// generator, and a node ID taken from the first interface with a hardware
+// address or, failing that, randomized with its multicast bit set per
+// RFC 9562 §5.1.
+func (g *UUIDGenerator) UUIDv1() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ts := uint64(g.now().UnixNano())/100 + gregorianOffset100ns
+	g.ensureV1StateLocked()
+
+	timeLow := ts & 0xffffffff
+	timeMid := (ts >> 32) & 0xffff
+	timeHiAndVersion := (ts >> 48) & 0x0fff
+
+	b := make([]byte, 16)
+	b[0] = byte(timeLow >> 24)
+	b[1] = byte(timeLow >> 16)
+	b[2] = byte(timeLow >> 8)
+	b[3] = byte(timeLow)
+	b[4] = byte(timeMid >> 8)
+	b[5] = byte(timeMid)
+	b[6] = byte(timeHiAndVersion >> 8)
+	b[7] = byte(timeHiAndVersion)
+	b[8] = byte(g.clockSeq >> 8)
+	b[9] = byte(g.clockSeq)
+	copy(b[10:], g.node[:])
+
+	b[6] = (b[6] & 0x0f) | 0x10 // Version 1
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+
+	return bytesToUUID(b)
+}
+
+// UUIDv6 generates a UUIDv6 (field-reordered UUIDv1) UUID using the
+// default generator.
+func UUIDv6() uuid.UUID { return defaultUUIDGenerator.UUIDv6() }
+
+// UUIDv6 generates a UUIDv6 UUID: the same 60-bit Gregorian timestamp,
+// clock sequence, and node ID as UUIDv1, but with the timestamp stored
+// most-significant-bits-first so UUIDv6 values sort lexicographically by
+// creation time.
+func (g *UUIDGenerator) UUIDv6() uuid.UUID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ts := uint64(g.now().UnixNano())/100 + gregorianOffset100ns
+	g.ensureV1StateLocked()
+
+	timeHigh := (ts >> 28) & 0xffffffff
+	timeMid := (ts >> 12) & 0xffff
+	timeLow := ts & 0xfff
+
+	b := make([]byte, 16)
+	b[0] = byte(timeHigh >> 24)
+	b[1] = byte(timeHigh >> 16)
+	b[2] = byte(timeHigh >> 8)
+	b[3] = byte(timeHigh)
+	b[4] = byte(timeMid >> 8)
+	b[5] = byte(timeMid)
+	b[6] = byte(timeLow >> 8)
+	b[7] = byte(timeLow)
+	b[8] = byte(g.clockSeq >> 8)
+	b[9] = byte(g.clockSeq)
+	copy(b[10:], g.node[:])
+
+	b[6] = (b[6] & 0x0f) | 0x60 // Version 6
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+
+	return bytesToUUID(b)
+}
+
+// ensureV1StateLocked lazily initializes the node ID and clock sequence
+// UUIDv1 and UUIDv6 share, so they're drawn once per generator instead of
+// on every call. g.mu must already be held.
+func (g *UUIDGenerator) ensureV1StateLocked() {
+	if g.v1Init {
+		return
+	}
+	g.node = g.resolveNodeID()
+
+	var seq [2]byte
+	g.randRead(seq[:])
+	g.clockSeq = (uint16(seq[0])<<8 | uint16(seq[1])) & 0x3fff
+
+	g.v1Init = true
+}
+
+// resolveNodeID returns the hardware address of the first network
+// interface that has one, or a randomized node ID with its multicast bit
+// set (per RFC 9562 §5.1, marking it as not derived from a MAC address)
+// if none is available.
+func (g *UUIDGenerator) resolveNodeID() [6]byte {
+	if ifaces, err := net.Interfaces(); err == nil {
+		for _, iface := range ifaces {
+			if len(iface.HardwareAddr) == 6 {
+				var node [6]byte
+				copy(node[:], iface.HardwareAddr)
+				return node
+			}
+		}
 	}
-	
-	copy(bytes[6:], random)
-	
-	// Set version (7) and variant bits
-	bytes[6] = (bytes[6] & 0x0f) | 0x70 // Version 7
-	bytes[8] = (bytes[8] & 0x3f) | 0x80 // Variant 10
-	
-	return bytesToUUID(bytes)
+
+	var node [6]byte
+	g.randRead(node[:])
+	node[0] |= 0x01
+	return node
+}
+
+// UUIDv8 generates a UUIDv8 (RFC 9562 custom) UUID using the default
+// generator.
+func UUIDv8(custom [16]byte) uuid.UUID { return defaultUUIDGenerator.UUIDv8(custom) }
+
+// UUIDv8 stamps custom with the UUIDv8 version and variant bits, leaving
+// every other bit as the caller supplied it. UUIDv8 carries no required
+// structure beyond those bits, so it needs no generator state.
+func (g *UUIDGenerator) UUIDv8(custom [16]byte) uuid.UUID {
+	b := make([]byte, 16)
+	copy(b, custom[:])
+
+	b[6] = (b[6] & 0x0f) | 0x80 // Version 8
+	b[8] = (b[8] & 0x3f) | 0x80 // Variant 10
+
+	return bytesToUUID(b)
 }
 
 // bytesToUUID converts 16 bytes to a UUID.
@@ -57,7 +290,7 @@ func bytesToUUID(bytes []byte) uuid.UUID {
 	if len(bytes) != 16 {
 		panic("UUID must be exactly 16 bytes")
 	}
-	
+
 	var u uuid.UUID
 	copy(u[:], bytes)
 	return u
@@ -68,7 +301,7 @@ func UUIDFromBytes(bytes []byte) (uuid.UUID, error) {
 	if len(bytes) != 16 {
 		return uuid.Nil, fmt.Errorf("UUID must be exactly 16 bytes, got %d", len(bytes))
 	}
-	
+
 	var u uuid.UUID
 	copy(u[:], bytes)
 	return u, nil
@@ -90,4 +323,4 @@ func UUIDBytes(u uuid.UUID) []byte {
 	bytes := make([]byte, 16)
 	copy(bytes, u[:])
 	return bytes
-}
\ No newline at end of file
+}